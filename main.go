@@ -49,18 +49,27 @@ func getLocalIP() string {
 }
 
 func main() {
+	// ✅ 命令列工具：lexiquest reconcile 整理找不到對應題庫的學習進度
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		os.Exit(GoApiFunc.RunReconcileCLI(os.Args[2:], os.Stdin, os.Stdout))
+	}
+
 	r := gin.Default()
 
 	// ✅ 允許區域網內所有設備存取
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept", "X-LexiQuest-Profile", "X-LexiQuest-PIN"},
 		AllowCredentials: true,
 	}))
 
-	// ✅ API 路由
-	api := r.Group("/api")
+	// ✅ API 路由（可用 X-LexiQuest-Profile Header 或 ?profile= 指定區域網內的用戶，設定了 PIN 的用戶需加上 X-LexiQuest-PIN）
+	api := r.Group("/api", GoApiFunc.ProfileMiddleware())
+	// ✅ 不需指定用戶的路由：房間以權杖識別玩家與主持人，發音音檔為公開資料
+	public := r.Group("/api")
+	// ✅ 列出與建立用戶不需先登入（PIN 錯誤時視為未登入）；建立老師仍需以老師身分通過驗證
+	signup := r.Group("/api", GoApiFunc.OptionalProfileMiddleware())
 	{
 		api.GET("/status", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"status": "API is running"})
@@ -68,14 +77,73 @@ func main() {
 		api.GET("/wordlists/all", GoApiFunc.ListAllWordlists)
 		api.GET("/wordlist/:category/:filename", GoApiFunc.LoadWordlistHandler)
 		api.GET("/wordlist/random/:category/:filename/:limit", GoApiFunc.GetRandomWordlist)
+		api.POST("/wordlist/enrich/:category/:filename", GoApiFunc.StartEnrichWordlist)
+		api.GET("/wordlist/enrich/:category/:filename", GoApiFunc.GetEnrichWordlistStatus)
+		api.GET("/wordlist/enriched/:category/:filename", GoApiFunc.GetEnrichedWordlist)
+		api.GET("/quiz/cloze/:category/:filename/:limit", GoApiFunc.GetClozeQuiz)
+		api.GET("/quiz/dictation/:category/:filename/:limit", GoApiFunc.GetDictationQuiz)
 
 		api.POST("/quiz/submit", GoApiFunc.SubmitQuiz)
+		api.POST("/quiz/undo", GoApiFunc.UndoQuizSubmission)
 		api.DELETE("/quiz/progress/:category/:filename", GoApiFunc.DeleteWordlistProgress)
+		api.DELETE("/quiz/progress/:category/:filename/:word", GoApiFunc.ResetWordProgress)
+		api.POST("/quiz/progress/:category/:filename/reset", GoApiFunc.ResetFilteredProgress)
+		api.GET("/quiz/progress/:category/:filename/state", GoApiFunc.GetWordStates)
+		api.PUT("/quiz/progress/:category/:filename/state", GoApiFunc.UpdateWordStates)
+		api.GET("/quiz/mistakes", GoApiFunc.GetMistakeReview)
+
+		api.POST("/quiz/timed/start", GoApiFunc.StartTimedQuiz)
+		api.POST("/quiz/timed/answer", GoApiFunc.AnswerTimedQuiz)
+		api.POST("/quiz/timed/finish", GoApiFunc.FinishTimedQuiz)
+		api.GET("/quiz/timed/bests", GoApiFunc.GetPersonalBests)
 
 		api.GET("/user", GoApiFunc.GetUser)
 		api.POST("/user", GoApiFunc.CreateUser)
 		api.PUT("/user", GoApiFunc.UpdateUser)
+		api.PUT("/user/settings", GoApiFunc.UpdateUserSettings)
+		api.PUT("/user/pin", GoApiFunc.UpdateMyPIN)
 		api.GET("/user/progress", GoApiFunc.GetUserProgressHandler)
+		api.GET("/user/progress/reconcile", GoApiFunc.GetReconcileReport)
+		api.POST("/user/progress/reconcile", GoApiFunc.ApplyReconcile)
+		api.GET("/user/stats", GoApiFunc.GetUserStats)
+		api.GET("/stats/wordlists", GoApiFunc.GetWordlistStats)
+		api.GET("/stats/activity", GoApiFunc.GetActivityStats)
+		api.GET("/stats/forecast", GoApiFunc.GetReviewForecast)
+		api.GET("/stats/difficult-words", GoApiFunc.GetDifficultWords)
+		api.GET("/word-of-the-day", GoApiFunc.GetWordOfTheDay)
+		api.PUT("/user/goal", GoApiFunc.UpdateGoal)
+
+		// ✅ 區域網多用戶：排行榜與挑戰
+		signup.GET("/profiles", GoApiFunc.ListProfiles)
+		signup.POST("/profiles", GoApiFunc.CreateProfile)
+		api.PUT("/profiles/:profile/role", GoApiFunc.UpdateProfileRole)
+		api.PUT("/profiles/:profile/pin", GoApiFunc.ResetProfilePIN)
+		api.GET("/leaderboard", GoApiFunc.GetLeaderboard)
+		api.GET("/challenges", GoApiFunc.ListChallenges)
+		api.POST("/challenges", GoApiFunc.CreateChallenge)
+		api.GET("/challenges/:id", GoApiFunc.GetChallenge)
+		api.POST("/challenges/:id/start", GoApiFunc.StartChallenge)
+		api.POST("/challenges/:id/submit", GoApiFunc.SubmitChallenge)
+		api.GET("/challenges/:id/results", GoApiFunc.GetChallengeResults)
+
+		// ✅ 多人即時測驗房間（SSE 推送題目與排名）
+		public.POST("/rooms", GoApiFunc.CreateRoom)
+		public.GET("/rooms/:code", GoApiFunc.GetRoom)
+		public.POST("/rooms/:code/join", GoApiFunc.JoinRoom)
+		public.GET("/rooms/:code/events", GoApiFunc.RoomEvents)
+		public.POST("/rooms/:code/start", GoApiFunc.StartRoom)
+		public.POST("/rooms/:code/next", GoApiFunc.NextRoomQuestion)
+		public.POST("/rooms/:code/finish", GoApiFunc.FinishRoom)
+		public.POST("/rooms/:code/answer", GoApiFunc.AnswerRoomQuestion)
+
+		// ✅ 班級與作業（老師指派題庫，學生查看作業進度）
+		api.GET("/classes", GoApiFunc.ListClasses)
+		api.POST("/classes", GoApiFunc.CreateClass)
+		api.PUT("/classes/:id/students", GoApiFunc.UpdateClassStudents)
+		api.POST("/classes/:id/assignments", GoApiFunc.CreateAssignment)
+		api.DELETE("/classes/:id/assignments/:assignmentId", GoApiFunc.DeleteAssignment)
+		api.GET("/classes/:id/report", GoApiFunc.GetClassReport)
+		api.GET("/assignments", GoApiFunc.GetMyAssignments)
 	}
 
 	// ✅ 用戶資料與題庫目錄
@@ -124,6 +192,13 @@ func main() {
 	})
 
 	r.GET("/dictionary/:word", GoApiFunc.GetWordHandler)
+	r.POST("/dictionary/batch", GoApiFunc.BatchLookupHandler)
+	public.GET("/audio/:word", GoApiFunc.GetPronunciationAudio)
+
+	// ✅ 字典快取管理
+	api.GET("/admin/dictionary/cache", GoApiFunc.GetDictionaryCache)
+	api.DELETE("/admin/dictionary/cache", GoApiFunc.PurgeDictionaryCache)
+	api.DELETE("/admin/dictionary/cache/:word", GoApiFunc.DeleteDictionaryCacheEntry)
 
 	// ✅ **提供 Vue 靜態文件 (`/LexiQuest`)**
 	r.Static("/LexiQuest", "./dist") // **確保對應 Vite 設定**
@@ -139,6 +214,9 @@ func main() {
 
 	server := &http.Server{Addr: addr, Handler: r}
 
+	// 🧹 定期清除閒置的測驗房間
+	stopRoomReaper := GoApiFunc.StartRoomReaper()
+
 	// 🚀 啟動伺服器
 	go func() {
 		fmt.Println("🚀 伺服器啟動成功！")
@@ -156,4 +234,6 @@ func main() {
 
 	fmt.Println("🛑 伺服器關閉")
 	server.Shutdown(context.Background())
+	stopRoomReaper()
+	GoApiFunc.FlushDictionaryCache()
 }
@@ -0,0 +1,309 @@
+package GoApiFunc
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+)
+
+// 發音音檔快取設定：
+// audioCacheDir：音檔與索引的存放位置
+// maxAudioSize：單一音檔大小上限，避免下載到非預期的大型檔案
+const (
+	audioCacheDir       = "./data/audio"
+	audioIndexFile      = "index.json"
+	maxAudioSize        = 5 << 20
+	audioRequestTimeout = 10 * time.Second
+	audioCacheMaxAge    = 365 * 24 * time.Hour
+)
+
+// AudioCacheEntry 為一個已下載的音檔
+type AudioCacheEntry struct {
+	URL         string    `json:"url"`
+	File        string    `json:"file"` // audioCacheDir 下的檔名
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	FetchedAt   time.Time `json:"fetchedAt"`
+	Words       []string  `json:"words,omitempty"` // 使用此音檔的單字（語言:整理過的字詞，見 dictionaryCacheKey）
+}
+
+// audioCache 以音檔網址為 key 管理已下載的音檔，索引存放於 data/audio/index.json；
+// 同時記錄單字對應的網址，讓已下載的音檔不需查詢字典即可提供（字典快取過期或離線時也能播放）
+type audioCache struct {
+	mu       sync.Mutex
+	dir      string
+	loaded   bool
+	entries  map[string]AudioCacheEntry
+	words    map[string]string        // 單字 → 音檔網址
+	inflight map[string]chan struct{} // 下載中的網址，同一個音檔只下載一次
+	client   *http.Client
+}
+
+var pronunciationAudio = &audioCache{
+	dir:      audioCacheDir,
+	entries:  make(map[string]AudioCacheEntry),
+	words:    make(map[string]string),
+	inflight: make(map[string]chan struct{}),
+	client:   &http.Client{Timeout: audioRequestTimeout},
+}
+
+// Stored 依單字（見 dictionaryCacheKey）取得已下載的音檔，不需查詢字典
+func (ac *audioCache) Stored(wordKey string) (AudioCacheEntry, bool) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	ac.ensureLoaded()
+
+	entry, found := ac.entries[ac.words[wordKey]]
+	if !found {
+		return AudioCacheEntry{}, false
+	}
+	if _, err := os.Stat(filepath.Join(ac.dir, entry.File)); err != nil {
+		return AudioCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Get 取得音檔並記錄使用此音檔的單字；尚未下載時先下載並存檔
+func (ac *audioCache) Get(audioURL, wordKey string) (AudioCacheEntry, error) {
+	for {
+		ac.mu.Lock()
+		ac.ensureLoaded()
+		if entry, found := ac.entries[audioURL]; found {
+			if _, err := os.Stat(filepath.Join(ac.dir, entry.File)); err == nil {
+				if updated, changed := ac.addWord(entry, wordKey); changed {
+					entry = updated
+					ac.saveIndex()
+				}
+				ac.mu.Unlock()
+				return entry, nil
+			}
+			delete(ac.entries, audioURL) // 檔案被刪除時重新下載
+		}
+		if wait, downloading := ac.inflight[audioURL]; downloading {
+			ac.mu.Unlock()
+			<-wait
+			continue
+		}
+		done := make(chan struct{})
+		ac.inflight[audioURL] = done
+		ac.mu.Unlock()
+
+		entry, err := ac.download(audioURL)
+
+		ac.mu.Lock()
+		delete(ac.inflight, audioURL)
+		if err == nil {
+			// 重新下載時保留原本使用此音檔的單字
+			for existing, existingURL := range ac.words {
+				if existingURL == audioURL {
+					entry.Words = append(entry.Words, existing)
+				}
+			}
+			ac.entries[audioURL] = entry
+			entry, _ = ac.addWord(entry, wordKey)
+			ac.saveIndex()
+		}
+		ac.mu.Unlock()
+		close(done)
+
+		return entry, err
+	}
+}
+
+// download 下載音檔並以內容判斷實際格式（不信任遠端的 Content-Type）
+func (ac *audioCache) download(audioURL string) (AudioCacheEntry, error) {
+	parsed, err := url.Parse(audioURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return AudioCacheEntry{}, fmt.Errorf("invalid audio url: %s", audioURL)
+	}
+
+	resp, err := ac.client.Get(audioURL)
+	if err != nil {
+		return AudioCacheEntry{}, fmt.Errorf("%w: %v", ErrNetworkUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return AudioCacheEntry{}, &UpstreamError{Provider: parsed.Host, StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAudioSize+1))
+	if err != nil {
+		return AudioCacheEntry{}, fmt.Errorf("failed to read audio: %v", err)
+	}
+	if len(body) > maxAudioSize {
+		return AudioCacheEntry{}, fmt.Errorf("audio file too large: %s", audioURL)
+	}
+
+	mime := mimetype.Detect(body)
+	if !strings.HasPrefix(mime.String(), "audio/") && !mime.Is("application/ogg") {
+		return AudioCacheEntry{}, fmt.Errorf("unexpected audio content type %s: %s", mime.String(), audioURL)
+	}
+
+	sum := sha1.Sum([]byte(audioURL))
+	entry := AudioCacheEntry{
+		URL:         audioURL,
+		File:        hex.EncodeToString(sum[:]) + mime.Extension(),
+		ContentType: mime.String(),
+		Size:        int64(len(body)),
+		FetchedAt:   time.Now(),
+	}
+
+	if err := os.MkdirAll(ac.dir, os.ModePerm); err != nil {
+		return AudioCacheEntry{}, err
+	}
+	if err := os.WriteFile(filepath.Join(ac.dir, entry.File), body, 0o644); err != nil {
+		return AudioCacheEntry{}, err
+	}
+
+	fmt.Println("🔊 已下載發音音檔:", audioURL)
+	return entry, nil
+}
+
+// addWord 記錄單字使用的音檔，回傳更新後的音檔與是否有變動（呼叫前需持有 ac.mu）
+func (ac *audioCache) addWord(entry AudioCacheEntry, wordKey string) (AudioCacheEntry, bool) {
+	if ac.words[wordKey] == entry.URL {
+		for _, existing := range entry.Words {
+			if existing == wordKey {
+				return entry, false
+			}
+		}
+	}
+	// 單字改用其他音檔時，從舊音檔的清單中移除
+	if previous, found := ac.entries[ac.words[wordKey]]; found {
+		previous.Words = removeString(previous.Words, wordKey)
+		ac.entries[previous.URL] = previous
+	}
+	entry.Words = append(removeString(entry.Words, wordKey), wordKey)
+	ac.entries[entry.URL] = entry
+	ac.words[wordKey] = entry.URL
+	return entry, true
+}
+
+// ensureLoaded 第一次使用時讀取音檔索引（呼叫前需持有 ac.mu）
+func (ac *audioCache) ensureLoaded() {
+	if ac.loaded {
+		return
+	}
+	ac.loaded = true
+
+	body, err := os.ReadFile(filepath.Join(ac.dir, audioIndexFile))
+	if err != nil {
+		return
+	}
+	var entries []AudioCacheEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		fmt.Println("⚠️ 無法讀取音檔索引，將重新建立:", err)
+		return
+	}
+	for _, entry := range entries {
+		ac.entries[entry.URL] = entry
+		for _, wordKey := range entry.Words {
+			ac.words[wordKey] = entry.URL
+		}
+	}
+}
+
+// saveIndex 寫入音檔索引（呼叫前需持有 ac.mu）
+func (ac *audioCache) saveIndex() {
+	entries := make([]AudioCacheEntry, 0, len(ac.entries))
+	for _, entry := range ac.entries {
+		entries = append(entries, entry)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		fmt.Println("❌ 無法序列化音檔索引:", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(ac.dir, audioIndexFile), data, 0o644); err != nil {
+		fmt.Println("❌ 無法寫入音檔索引:", err)
+	}
+}
+
+// localAudioURL 回傳本機音檔代理的網址，前端可直接作為 <audio> 的來源
+func localAudioURL(word, lang string) string {
+	u := "/api/audio/" + url.PathEscape(word)
+	if lang != defaultDictionaryLang {
+		u += "?lang=" + url.QueryEscape(lang)
+	}
+	return u
+}
+
+// GetPronunciationAudio 提供單字的發音音檔：第一次請求時從字典資料中的網址下載並存檔，
+// 之後直接由本機提供，支援 Range 請求與快取標頭，離線與區域網路教室中也能播放
+func GetPronunciationAudio(c *gin.Context) {
+	word := c.Param("word")
+	lang, ok := normalizeLang(c.Query("lang"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid language code %q", c.Query("lang"))})
+		return
+	}
+
+	// ✅ 已下載過的音檔直接提供，不需查詢字典
+	wordKey := dictionaryCacheKey(word, lang)
+	if entry, found := pronunciationAudio.Stored(wordKey); found {
+		serveAudioFile(c, entry)
+		return
+	}
+
+	data, err := lookupWord(word, lang)
+	if err != nil {
+		status, msg := dictionaryErrorResponse(err, word, lang)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	if data.Audio == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("No pronunciation audio for %q", word)})
+		return
+	}
+
+	entry, err := pronunciationAudio.Get(data.Audio, wordKey)
+	if err != nil {
+		status, msg := dictionaryErrorResponse(err, word, lang)
+		if status == http.StatusInternalServerError {
+			msg = "Failed to fetch pronunciation audio"
+		}
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+	serveAudioFile(c, entry)
+}
+
+// serveAudioFile 回傳已下載的音檔，支援 Range 請求與快取標頭
+func serveAudioFile(c *gin.Context, entry AudioCacheEntry) {
+	file, err := os.Open(filepath.Join(pronunciationAudio.dir, entry.File))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read pronunciation audio"})
+		return
+	}
+	defer file.Close()
+
+	// ✅ 音檔內容不會改變，以檔名（網址雜湊）作為 ETag 並允許長期快取
+	c.Header("Content-Type", entry.ContentType)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(audioCacheMaxAge.Seconds())))
+	c.Header("ETag", `"`+strings.TrimSuffix(entry.File, filepath.Ext(entry.File))+`"`)
+	http.ServeContent(c.Writer, c.Request, entry.File, entry.FetchedAt, file)
+}
+
+// removeString 回傳移除指定字串後的新切片
+func removeString(values []string, target string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != target {
+			result = append(result, value)
+		}
+	}
+	return result
+}
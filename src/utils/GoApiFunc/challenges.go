@@ -0,0 +1,501 @@
+package GoApiFunc
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 挑戰設定：
+// 挑戰固定題庫、亂數種子與開放時間，所有用戶拿到相同的題目，依答對數與用時排名
+const (
+	challengesFilePath      = "./data/userdata/challenges.json"
+	challengeDefaultLimit   = 10
+	challengeDefaultMinutes = 60
+	challengeMaxMinutes     = 7 * 24 * 60
+	challengeModeName       = "challenge" // 寫入提交紀錄的測驗模式
+
+	challengeStatusUpcoming = "upcoming"
+	challengeStatusActive   = "active"
+	challengeStatusEnded    = "ended"
+)
+
+// challengesMu 保護 challenges.json 的讀寫，避免多位用戶同時提交時互相覆蓋
+var challengesMu sync.Mutex
+
+// challengeQuestion 為建立挑戰時固定下來的題目（含答案，不會回傳給前端）
+type challengeQuestion struct {
+	Word        string `json:"word"`
+	Translation string `json:"translation"`
+	Type        string `json:"type"`
+}
+
+// ChallengeResult 為一位用戶的挑戰成績
+type ChallengeResult struct {
+	Rank        int       `json:"rank"`
+	Profile     string    `json:"profile"`
+	Username    string    `json:"username"`
+	Correct     int       `json:"correct"`
+	Total       int       `json:"total"`
+	DurationMs  int64     `json:"durationMs"` // 從開始作答到提交的伺服器端用時
+	SubmittedAt time.Time `json:"submittedAt"`
+}
+
+// Challenge 為一個區域網挑戰
+type Challenge struct {
+	ID        string               `json:"id"`
+	Title     string               `json:"title"`
+	Category  string               `json:"category"`
+	Filename  string               `json:"filename"`
+	Seed      int64                `json:"seed"`
+	Limit     int                  `json:"limit"`
+	StartsAt  time.Time            `json:"startsAt"`
+	EndsAt    time.Time            `json:"endsAt"`
+	CreatedBy string               `json:"createdBy"`
+	CreatedAt time.Time            `json:"createdAt"`
+	Questions []challengeQuestion  `json:"questions"`
+	Started   map[string]time.Time `json:"started,omitempty"` // 用戶 → 開始作答時間
+	Results   []ChallengeResult    `json:"results,omitempty"`
+}
+
+// ChallengeView 為回傳給前端的挑戰資訊（不含題目與答案）
+type ChallengeView struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Category     string    `json:"category"`
+	Filename     string    `json:"filename"`
+	Seed         int64     `json:"seed"`
+	Limit        int       `json:"limit"`
+	StartsAt     time.Time `json:"startsAt"`
+	EndsAt       time.Time `json:"endsAt"`
+	CreatedBy    string    `json:"createdBy"`
+	Status       string    `json:"status"`
+	Participants int       `json:"participants"`
+	Submitted    bool      `json:"submitted"` // 請求的用戶是否已提交
+}
+
+// status 依目前時間判斷挑戰狀態
+func (ch *Challenge) status(now time.Time) string {
+	switch {
+	case now.Before(ch.StartsAt):
+		return challengeStatusUpcoming
+	case now.Before(ch.EndsAt):
+		return challengeStatusActive
+	default:
+		return challengeStatusEnded
+	}
+}
+
+// result 回傳用戶的成績，尚未提交時回傳 nil
+func (ch *Challenge) result(profile string) *ChallengeResult {
+	for i := range ch.Results {
+		if ch.Results[i].Profile == profile {
+			return &ch.Results[i]
+		}
+	}
+	return nil
+}
+
+// view 轉為回傳給 profile 的挑戰資訊
+func (ch *Challenge) view(profile string, now time.Time) ChallengeView {
+	return ChallengeView{
+		ID:           ch.ID,
+		Title:        ch.Title,
+		Category:     ch.Category,
+		Filename:     ch.Filename,
+		Seed:         ch.Seed,
+		Limit:        ch.Limit,
+		StartsAt:     ch.StartsAt,
+		EndsAt:       ch.EndsAt,
+		CreatedBy:    ch.CreatedBy,
+		Status:       ch.status(now),
+		Participants: len(ch.Results),
+		Submitted:    ch.result(profile) != nil,
+	}
+}
+
+// rankResults 依答對數（多者優先）與用時（短者優先）排序並編上名次，兩者皆相同時名次相同
+func (ch *Challenge) rankResults() {
+	sort.SliceStable(ch.Results, func(i, j int) bool {
+		a, b := ch.Results[i], ch.Results[j]
+		if a.Correct != b.Correct {
+			return a.Correct > b.Correct
+		}
+		if a.DurationMs != b.DurationMs {
+			return a.DurationMs < b.DurationMs
+		}
+		return a.SubmittedAt.Before(b.SubmittedAt)
+	})
+	for i := range ch.Results {
+		prev := i - 1
+		if prev >= 0 && ch.Results[i].Correct == ch.Results[prev].Correct && ch.Results[i].DurationMs == ch.Results[prev].DurationMs {
+			ch.Results[i].Rank = ch.Results[prev].Rank
+		} else {
+			ch.Results[i].Rank = i + 1
+		}
+	}
+}
+
+// challengeQuestions 以種子打亂題庫並取前 limit 題，相同的題庫與種子一定得到相同的題目
+func challengeQuestions(words []map[string]string, seed int64, limit int) []challengeQuestion {
+	order := rand.New(rand.NewSource(seed)).Perm(len(words))
+	questions := make([]challengeQuestion, 0, min(limit, len(words)))
+	for _, i := range order[:min(limit, len(words))] {
+		questions = append(questions, challengeQuestion{
+			Word:        words[i]["word"],
+			Translation: words[i]["translation"],
+			Type:        words[i]["type"],
+		})
+	}
+	return questions
+}
+
+// loadChallenges 讀取所有挑戰；檔案不存在時回傳空列表（呼叫前需持有 challengesMu）
+func loadChallenges() ([]*Challenge, error) {
+	data, err := os.ReadFile(challengesFilePath)
+	if os.IsNotExist(err) {
+		return []*Challenge{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var challenges []*Challenge
+	if err := json.Unmarshal(data, &challenges); err != nil {
+		return nil, err
+	}
+	return challenges, nil
+}
+
+// saveChallenges 寫入所有挑戰（呼叫前需持有 challengesMu）
+func saveChallenges(challenges []*Challenge) error {
+	return writeFileAtomic(challengesFilePath, challenges)
+}
+
+// findChallenge 依 ID 尋找挑戰
+func findChallenge(challenges []*Challenge, id string) *Challenge {
+	for _, ch := range challenges {
+		if ch.ID == id {
+			return ch
+		}
+	}
+	return nil
+}
+
+// CreateChallengeRequest 定義建立挑戰的請求資料
+type CreateChallengeRequest struct {
+	Title    string     `json:"title"`
+	Category string     `json:"category"`
+	Filename string     `json:"filename"`
+	Limit    int        `json:"limit"`    // 題數，預設 10
+	Seed     int64      `json:"seed"`     // 亂數種子，未提供時隨機產生
+	StartsAt *time.Time `json:"startsAt"` // 開放時間，預設為現在
+	Minutes  int        `json:"minutes"`  // 開放分鐘數，預設 60
+}
+
+// CreateChallenge 建立挑戰：以種子從題庫選出固定的題目，並設定開放時間
+func CreateChallenge(c *gin.Context) {
+	var req CreateChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Category == "" || req.Filename == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的題庫與挑戰設定"})
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = challengeDefaultLimit
+	}
+	if req.Minutes <= 0 {
+		req.Minutes = challengeDefaultMinutes
+	}
+	if req.Minutes > challengeMaxMinutes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "挑戰開放時間最長為 7 天"})
+		return
+	}
+	if req.Seed == 0 {
+		req.Seed = time.Now().UnixNano()
+	}
+
+	if !validWordlistRef(req.Category, req.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "題庫名稱不合法"})
+		return
+	}
+	words, err := parseWordlistFile(filepath.Join(wordlistPath, req.Category, req.Filename+".txt"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到指定的題庫"})
+		return
+	}
+	if len(words) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "題庫為空，無可用單字"})
+		return
+	}
+
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if req.Title == "" {
+		req.Title = req.Category + " / " + req.Filename
+	}
+
+	challenge := &Challenge{
+		ID:        newSessionID(),
+		Title:     req.Title,
+		Category:  req.Category,
+		Filename:  req.Filename,
+		Seed:      req.Seed,
+		Limit:     req.Limit,
+		StartsAt:  startsAt,
+		EndsAt:    startsAt.Add(time.Duration(req.Minutes) * time.Minute),
+		CreatedBy: requestProfile(c),
+		CreatedAt: now,
+		Questions: challengeQuestions(words, req.Seed, req.Limit),
+	}
+	challenge.Limit = len(challenge.Questions)
+
+	challengesMu.Lock()
+	defer challengesMu.Unlock()
+	challenges, err := loadChallenges()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取挑戰資料"})
+		return
+	}
+	challenges = append(challenges, challenge)
+	if err := saveChallenges(challenges); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法建立挑戰"})
+		return
+	}
+
+	c.JSON(http.StatusOK, challenge.view(requestProfile(c), now))
+}
+
+// ListChallenges 列出所有挑戰（進行中、即將開始、已結束），可用 ?status= 篩選
+func ListChallenges(c *gin.Context) {
+	statusFilter := c.Query("status")
+
+	challengesMu.Lock()
+	challenges, err := loadChallenges()
+	challengesMu.Unlock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取挑戰資料"})
+		return
+	}
+
+	now := time.Now()
+	profile := requestProfile(c)
+	views := []ChallengeView{}
+	for _, ch := range challenges {
+		view := ch.view(profile, now)
+		if statusFilter != "" && view.Status != statusFilter {
+			continue
+		}
+		views = append(views, view)
+	}
+	sort.SliceStable(views, func(i, j int) bool { return views[i].StartsAt.After(views[j].StartsAt) })
+
+	c.JSON(http.StatusOK, views)
+}
+
+// GetChallenge 取得單一挑戰的資訊
+func GetChallenge(c *gin.Context) {
+	challengesMu.Lock()
+	challenges, err := loadChallenges()
+	challengesMu.Unlock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取挑戰資料"})
+		return
+	}
+
+	challenge := findChallenge(challenges, c.Param("id"))
+	if challenge == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "挑戰不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, challenge.view(requestProfile(c), time.Now()))
+}
+
+// StartChallenge 開始作答：記錄伺服器端的開始時間並回傳題目（不含答案）
+// 重複呼叫會回傳相同的題目，但開始時間以第一次為準
+func StartChallenge(c *gin.Context) {
+	profile := requestProfile(c)
+	now := time.Now()
+
+	challengesMu.Lock()
+	defer challengesMu.Unlock()
+	challenges, err := loadChallenges()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取挑戰資料"})
+		return
+	}
+	challenge := findChallenge(challenges, c.Param("id"))
+	if challenge == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "挑戰不存在"})
+		return
+	}
+	if status := challenge.status(now); status != challengeStatusActive {
+		c.JSON(http.StatusForbidden, gin.H{"error": "挑戰目前未開放", "status": status, "startsAt": challenge.StartsAt, "endsAt": challenge.EndsAt})
+		return
+	}
+	if challenge.result(profile) != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "已提交過此挑戰"})
+		return
+	}
+
+	if challenge.Started == nil {
+		challenge.Started = make(map[string]time.Time)
+	}
+	startedAt, started := challenge.Started[profile]
+	if !started {
+		startedAt = now
+		challenge.Started[profile] = now
+		if err := saveChallenges(challenges); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "無法開始挑戰"})
+			return
+		}
+	}
+
+	prompts := make([]gin.H, 0, len(challenge.Questions))
+	for i, q := range challenge.Questions {
+		prompts = append(prompts, gin.H{"index": i, "translation": q.Translation, "type": q.Type})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"challenge": challenge.view(profile, now),
+		"startedAt": startedAt,
+		"questions": prompts,
+	})
+}
+
+// SubmitChallengeRequest 定義提交挑戰的作答內容
+type SubmitChallengeRequest struct {
+	Answers []struct {
+		Index  int    `json:"index"`
+		Answer string `json:"answer"`
+	} `json:"answers"`
+}
+
+// SubmitChallenge 批改挑戰作答並記錄成績；每位用戶只能提交一次，作答結果同時計入學習進度
+func SubmitChallenge(c *gin.Context) {
+	var req SubmitChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的作答資料"})
+		return
+	}
+
+	profile := requestProfile(c)
+	now := time.Now()
+
+	// ✅ 先鎖定用戶存檔再鎖定挑戰資料，與其他修改進度的請求維持相同的鎖定順序
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	challengesMu.Lock()
+	defer challengesMu.Unlock()
+	challenges, err := loadChallenges()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取挑戰資料"})
+		return
+	}
+	challenge := findChallenge(challenges, c.Param("id"))
+	if challenge == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "挑戰不存在"})
+		return
+	}
+	if status := challenge.status(now); status != challengeStatusActive {
+		c.JSON(http.StatusForbidden, gin.H{"error": "挑戰目前未開放", "status": status})
+		return
+	}
+	if challenge.result(profile) != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "已提交過此挑戰"})
+		return
+	}
+	startedAt, started := challenge.Started[profile]
+	if !started {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請先開始挑戰"})
+		return
+	}
+
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	// ✅ 依題號批改，同一題重複作答時以第一次為準，未作答的題目視為答錯
+	answers := make(map[int]string, len(req.Answers))
+	for _, a := range req.Answers {
+		if _, exists := answers[a.Index]; !exists && a.Index >= 0 && a.Index < len(challenge.Questions) {
+			answers[a.Index] = a.Answer
+		}
+	}
+	outcomes := make([]QuizOutcome, 0, len(challenge.Questions))
+	graded := make([]gin.H, 0, len(challenge.Questions))
+	correct := 0
+	for i, q := range challenge.Questions {
+		answer := answers[i]
+		ok := normalizeAnswer(answer) == normalizeAnswer(q.Word)
+		if ok {
+			correct++
+		}
+		outcomes = append(outcomes, QuizOutcome{Word: q.Word, Correct: ok})
+		graded = append(graded, gin.H{"index": i, "answer": answer, "expected": q.Word, "correct": ok})
+	}
+
+	duration := now.Sub(startedAt)
+	recordQuizOutcomes(userData, challenge.Category, challenge.Filename, challengeModeName, outcomes, duration, now)
+	if err := SaveUserData(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法儲存學習進度"})
+		return
+	}
+
+	username := userData.Username
+	if username == "" {
+		username = profile
+	}
+	challenge.Results = append(challenge.Results, ChallengeResult{
+		Profile:     profile,
+		Username:    username,
+		Correct:     correct,
+		Total:       len(challenge.Questions),
+		DurationMs:  duration.Milliseconds(),
+		SubmittedAt: now,
+	})
+	challenge.rankResults()
+	if err := saveChallenges(challenges); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法儲存挑戰成績"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"result":  challenge.result(profile),
+		"answers": graded,
+	})
+}
+
+// GetChallengeResults 回傳挑戰的排名（進行中也可查看目前的排名）
+func GetChallengeResults(c *gin.Context) {
+	challengesMu.Lock()
+	challenges, err := loadChallenges()
+	challengesMu.Unlock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取挑戰資料"})
+		return
+	}
+
+	challenge := findChallenge(challenges, c.Param("id"))
+	if challenge == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "挑戰不存在"})
+		return
+	}
+	results := challenge.Results
+	if results == nil {
+		results = []ChallengeResult{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"challenge": challenge.view(requestProfile(c), time.Now()),
+		"results":   results,
+	})
+}
@@ -0,0 +1,520 @@
+package GoApiFunc
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 班級與作業設定：
+// 老師建立班級並指派題庫，作業包含截止日期與目標熟練度；
+// 學生的進度直接由各自的用戶檔案計算，不另外記錄
+const (
+	classesFilePath            = "./data/userdata/classes.json"
+	assignmentDefaultLevel     = "familiar"
+	assignmentDefaultPercent   = 80.0
+	assignmentStatusPending    = "pending"
+	assignmentStatusCompleted  = "completed"
+	assignmentStatusOverdue    = "overdue"
+	classReportMissingStudent  = "找不到學生的用戶檔案"
+	classReportMissingWordlist = "找不到作業的題庫"
+)
+
+// classesMu 保護 classes.json 的讀寫
+var classesMu sync.Mutex
+
+// masteryRank 為熟練度的高低順序，用於判斷單字是否達到作業目標
+var masteryRank = map[string]int{"new": 0, "learning": 1, "familiar": 2, "mastered": 3}
+
+// Assignment 為指派給班級的題庫作業
+type Assignment struct {
+	ID            string    `json:"id"`
+	Title         string    `json:"title"`
+	Category      string    `json:"category"`
+	Filename      string    `json:"filename"`
+	DueDate       string    `json:"dueDate"`       // 截止日期 YYYY-MM-DD（含當天，依學生時區判斷）
+	TargetLevel   string    `json:"targetLevel"`   // 目標熟練度：learning、familiar 或 mastered
+	TargetPercent float64   `json:"targetPercent"` // 需達到目標熟練度的單字比例（0～100）
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Class 為老師建立的班級
+type Class struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Teacher     string       `json:"teacher"`  // 建立班級的老師（用戶名稱）
+	Students    []string     `json:"students"` // 學生的用戶名稱
+	Assignments []Assignment `json:"assignments"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// AssignmentProgress 為一位學生在一份作業的進度
+type AssignmentProgress struct {
+	Total    int           `json:"total"`
+	Reached  int           `json:"reached"` // 已達目標熟練度（或標記為已熟悉）的單字數
+	Percent  float64       `json:"percent"` // Reached 佔全部單字的百分比
+	Mastery  MasteryCounts `json:"mastery"`
+	Status   string        `json:"status"`   // pending、completed 或 overdue
+	DaysLeft int           `json:"daysLeft"` // 距離截止日的天數，已逾期時為負數
+	Error    string        `json:"error,omitempty"`
+}
+
+// hasStudent 判斷用戶是否為班級學生
+func (cl *Class) hasStudent(profile string) bool {
+	for _, student := range cl.Students {
+		if student == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// findAssignment 依 ID 尋找作業，回傳其索引，找不到時回傳 -1
+func (cl *Class) findAssignment(id string) int {
+	for i := range cl.Assignments {
+		if cl.Assignments[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// loadClasses 讀取所有班級；檔案不存在時回傳空列表（呼叫前需持有 classesMu）
+func loadClasses() ([]*Class, error) {
+	data, err := os.ReadFile(classesFilePath)
+	if os.IsNotExist(err) {
+		return []*Class{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var classes []*Class
+	if err := json.Unmarshal(data, &classes); err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
+
+// saveClasses 寫入所有班級（呼叫前需持有 classesMu）
+func saveClasses(classes []*Class) error {
+	return writeFileAtomic(classesFilePath, classes)
+}
+
+// findClass 依 ID 尋找班級
+func findClass(classes []*Class, id string) *Class {
+	for _, cl := range classes {
+		if cl.ID == id {
+			return cl
+		}
+	}
+	return nil
+}
+
+// normalizeStudents 去除重複與空白的學生名稱，並回傳不存在的用戶
+func normalizeStudents(students []string) (valid, unknown []string) {
+	seen := map[string]bool{}
+	valid = []string{}
+	for _, student := range students {
+		student = strings.TrimSpace(student)
+		if student == "" || seen[student] {
+			continue
+		}
+		seen[student] = true
+		if !validProfileName(student) || !profileExists(student) {
+			unknown = append(unknown, student)
+			continue
+		}
+		valid = append(valid, student)
+	}
+	return valid, unknown
+}
+
+// assignmentProgress 以學生的學習進度計算作業完成情況，熟練度與題庫統計相同（見 wordMastery）：
+// 標記為已熟悉（known）的單字視為已達標，暫停的單字不算達標
+func assignmentProgress(userData *UserData, assignment Assignment, now time.Time) AssignmentProgress {
+	result := AssignmentProgress{Status: assignmentStatusPending}
+
+	today, _ := time.Parse(dayLayout, userDay(userData, now))
+	if due, err := time.Parse(dayLayout, assignment.DueDate); err == nil {
+		result.DaysLeft = int(due.Sub(today).Hours() / 24)
+	}
+
+	words, err := parseWordlistFile(filepath.Join(wordlistPath, assignment.Category, assignment.Filename+".txt"))
+	if err != nil {
+		result.Error = classReportMissingWordlist
+		return result
+	}
+
+	userData.applySharedWeights(assignment.Category, assignment.Filename)
+	progress := userData.Progress[assignment.Category][assignment.Filename]
+	states := userData.WordStates[assignment.Category][assignment.Filename]
+	for _, word := range words {
+		weight, seen := progress[word["word"]]
+		level := wordMastery(weight, seen, states[word["word"]])
+		result.Mastery.add(level)
+		if rank, ranked := masteryRank[level]; ranked && rank >= masteryRank[assignment.TargetLevel] {
+			result.Reached++
+		}
+	}
+
+	result.Total = len(words)
+	if result.Total > 0 {
+		result.Percent = math.Round(float64(result.Reached)/float64(result.Total)*1000) / 10
+	}
+	switch {
+	case result.Total > 0 && result.Percent >= assignment.TargetPercent:
+		result.Status = assignmentStatusCompleted
+	case result.DaysLeft < 0:
+		result.Status = assignmentStatusOverdue
+	}
+	return result
+}
+
+// teacherClass 讀取路徑中的班級並確認請求的用戶是該班老師（呼叫前需持有 classesMu）
+// 失敗時已回傳錯誤，回傳 nil
+func teacherClass(c *gin.Context) ([]*Class, *Class) {
+	if !requireTeacher(c) {
+		return nil, nil
+	}
+	classes, err := loadClasses()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取班級資料"})
+		return nil, nil
+	}
+	class := findClass(classes, c.Param("id"))
+	if class == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "班級不存在"})
+		return nil, nil
+	}
+	if class.Teacher != requestProfile(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有班級的老師可以操作"})
+		return nil, nil
+	}
+	return classes, class
+}
+
+// ClassRequest 定義建立班級或更新學生名單的請求資料
+type ClassRequest struct {
+	Name     string   `json:"name"`
+	Students []string `json:"students"` // 學生的用戶名稱（需先以 POST /api/profiles 建立）
+}
+
+// CreateClass 老師建立班級
+func CreateClass(c *gin.Context) {
+	var req ClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供班級名稱"})
+		return
+	}
+	if !requireTeacher(c) {
+		return
+	}
+	students, unknown := normalizeStudents(req.Students)
+	if len(unknown) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "找不到部分學生的用戶", "unknown": unknown})
+		return
+	}
+
+	class := &Class{
+		ID:          newSessionID(),
+		Name:        strings.TrimSpace(req.Name),
+		Teacher:     requestProfile(c),
+		Students:    students,
+		Assignments: []Assignment{},
+		CreatedAt:   time.Now(),
+	}
+
+	classesMu.Lock()
+	defer classesMu.Unlock()
+	classes, err := loadClasses()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取班級資料"})
+		return
+	}
+	if err := saveClasses(append(classes, class)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法建立班級"})
+		return
+	}
+	c.JSON(http.StatusOK, class)
+}
+
+// ListClasses 列出請求用戶的班級：老師為自己建立的班級，學生為自己加入的班級
+func ListClasses(c *gin.Context) {
+	classesMu.Lock()
+	classes, err := loadClasses()
+	classesMu.Unlock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取班級資料"})
+		return
+	}
+
+	profile := requestProfile(c)
+	result := []*Class{}
+	for _, class := range classes {
+		if class.Teacher == profile || class.hasStudent(profile) {
+			result = append(result, class)
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdateClassStudents 老師更新班級的學生名單（以請求中的名單取代）
+func UpdateClassStudents(c *gin.Context) {
+	var req ClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供學生名單"})
+		return
+	}
+	students, unknown := normalizeStudents(req.Students)
+	if len(unknown) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "找不到部分學生的用戶", "unknown": unknown})
+		return
+	}
+
+	classesMu.Lock()
+	defer classesMu.Unlock()
+	classes, class := teacherClass(c)
+	if class == nil {
+		return
+	}
+	class.Students = students
+	if name := strings.TrimSpace(req.Name); name != "" {
+		class.Name = name
+	}
+	if err := saveClasses(classes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法更新班級"})
+		return
+	}
+	c.JSON(http.StatusOK, class)
+}
+
+// CreateAssignmentRequest 定義指派作業的請求資料
+type CreateAssignmentRequest struct {
+	Title         string  `json:"title"`
+	Category      string  `json:"category"`
+	Filename      string  `json:"filename"`
+	DueDate       string  `json:"dueDate"`       // YYYY-MM-DD
+	TargetLevel   string  `json:"targetLevel"`   // 預設 familiar
+	TargetPercent float64 `json:"targetPercent"` // 預設 80
+}
+
+// CreateAssignment 老師指派題庫作業給班級
+func CreateAssignment(c *gin.Context) {
+	var req CreateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Category == "" || req.Filename == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的題庫與作業設定"})
+		return
+	}
+	if _, err := time.Parse(dayLayout, req.DueDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "截止日期格式應為 YYYY-MM-DD"})
+		return
+	}
+	if req.TargetLevel == "" {
+		req.TargetLevel = assignmentDefaultLevel
+	}
+	if rank, ok := masteryRank[req.TargetLevel]; !ok || rank == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "目標熟練度只能是 learning、familiar 或 mastered"})
+		return
+	}
+	if req.TargetPercent == 0 {
+		req.TargetPercent = assignmentDefaultPercent
+	}
+	if req.TargetPercent < 0 || req.TargetPercent > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "目標比例需介於 0～100"})
+		return
+	}
+	if !validWordlistRef(req.Category, req.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "題庫名稱不合法"})
+		return
+	}
+	if _, err := os.Stat(filepath.Join(wordlistPath, req.Category, req.Filename+".txt")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到指定的題庫"})
+		return
+	}
+	if req.Title == "" {
+		req.Title = req.Category + " / " + req.Filename
+	}
+
+	classesMu.Lock()
+	defer classesMu.Unlock()
+	classes, class := teacherClass(c)
+	if class == nil {
+		return
+	}
+
+	assignment := Assignment{
+		ID:            newSessionID(),
+		Title:         req.Title,
+		Category:      req.Category,
+		Filename:      req.Filename,
+		DueDate:       req.DueDate,
+		TargetLevel:   req.TargetLevel,
+		TargetPercent: req.TargetPercent,
+		CreatedAt:     time.Now(),
+	}
+	class.Assignments = append(class.Assignments, assignment)
+	if err := saveClasses(classes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法指派作業"})
+		return
+	}
+	c.JSON(http.StatusOK, assignment)
+}
+
+// DeleteAssignment 老師刪除班級的作業（不影響學生的學習進度）
+func DeleteAssignment(c *gin.Context) {
+	classesMu.Lock()
+	defer classesMu.Unlock()
+	classes, class := teacherClass(c)
+	if class == nil {
+		return
+	}
+
+	i := class.findAssignment(c.Param("assignmentId"))
+	if i < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "作業不存在"})
+		return
+	}
+	class.Assignments = append(class.Assignments[:i], class.Assignments[i+1:]...)
+	if err := saveClasses(classes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法刪除作業"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "作業已刪除"})
+}
+
+// StudentAssignment 為學生看到的一份作業與自己的進度
+type StudentAssignment struct {
+	Assignment
+	ClassID   string             `json:"classId"`
+	ClassName string             `json:"className"`
+	Progress  AssignmentProgress `json:"progress"`
+}
+
+// GetMyAssignments 列出請求用戶在所有班級中的作業與完成情況，依截止日期排序
+// 可用 ?status=pending|completed|overdue 篩選
+func GetMyAssignments(c *gin.Context) {
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	classesMu.Lock()
+	classes, err := loadClasses()
+	classesMu.Unlock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取班級資料"})
+		return
+	}
+
+	now := time.Now()
+	profile := requestProfile(c)
+	statusFilter := c.Query("status")
+	assignments := []StudentAssignment{}
+	for _, class := range classes {
+		if !class.hasStudent(profile) {
+			continue
+		}
+		for _, assignment := range class.Assignments {
+			progress := assignmentProgress(userData, assignment, now)
+			if statusFilter != "" && progress.Status != statusFilter {
+				continue
+			}
+			assignments = append(assignments, StudentAssignment{
+				Assignment: assignment,
+				ClassID:    class.ID,
+				ClassName:  class.Name,
+				Progress:   progress,
+			})
+		}
+	}
+	sort.SliceStable(assignments, func(i, j int) bool { return assignments[i].DueDate < assignments[j].DueDate })
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+// StudentReport 為班級報告中一位學生的學習情況
+type StudentReport struct {
+	Profile        string                        `json:"profile"`
+	Username       string                        `json:"username"`
+	LastActive     string                        `json:"lastActive,omitempty"` // 最後一次學習的日期
+	WeeklyReviewed int                           `json:"weeklyReviewed"`       // 最近 7 天的複習數
+	WeeklyCorrect  int                           `json:"weeklyCorrect"`
+	Streak         int                           `json:"streak"`
+	Assignments    map[string]AssignmentProgress `json:"assignments"` // 作業 ID → 進度
+	Error          string                        `json:"error,omitempty"`
+}
+
+// AssignmentReport 為班級報告中一份作業的整體完成情況
+type AssignmentReport struct {
+	Assignment
+	Completed int `json:"completed"`
+	Overdue   int `json:"overdue"`
+	Pending   int `json:"pending"`
+}
+
+// GetClassReport 老師查看班級中每位學生的作業進度與最近的學習量
+func GetClassReport(c *gin.Context) {
+	classesMu.Lock()
+	_, class := teacherClass(c)
+	classesMu.Unlock()
+	if class == nil {
+		return
+	}
+
+	now := time.Now()
+	assignments := make([]AssignmentReport, len(class.Assignments))
+	for i, assignment := range class.Assignments {
+		assignments[i] = AssignmentReport{Assignment: assignment}
+	}
+
+	students := []StudentReport{}
+	for _, student := range class.Students {
+		report := StudentReport{Profile: student, Username: student, Assignments: map[string]AssignmentProgress{}}
+		userData, err := loadProfileData(student)
+		if err != nil {
+			report.Error = classReportMissingStudent
+			students = append(students, report)
+			continue
+		}
+		if userData.Username != "" {
+			report.Username = userData.Username
+		}
+		for day, activity := range userData.Daily {
+			if activity != nil && activity.Reviewed > 0 && day > report.LastActive {
+				report.LastActive = day
+			}
+		}
+		report.WeeklyReviewed, report.WeeklyCorrect = weeklyActivity(userData, now)
+		report.Streak, _ = computeStreaks(userData, now)
+
+		for i, assignment := range class.Assignments {
+			progress := assignmentProgress(userData, assignment, now)
+			report.Assignments[assignment.ID] = progress
+			switch progress.Status {
+			case assignmentStatusCompleted:
+				assignments[i].Completed++
+			case assignmentStatusOverdue:
+				assignments[i].Overdue++
+			default:
+				assignments[i].Pending++
+			}
+		}
+		students = append(students, report)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"class":       gin.H{"id": class.ID, "name": class.Name, "teacher": class.Teacher},
+		"assignments": assignments,
+		"students":    students,
+		"generatedAt": now,
+	})
+}
@@ -0,0 +1,196 @@
+package GoApiFunc
+
+import (
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clozeBlank 為例句中挖空的佔位符號
+const clozeBlank = "_____"
+
+// ClozeQuestion 定義一題填空題：挖空後的例句與預期答案
+type ClozeQuestion struct {
+	Word        string `json:"word"`
+	Translation string `json:"translation"`
+	Type        string `json:"type"`
+	Sentence    string `json:"sentence"` // 已挖空的例句
+	Answer      string `json:"answer"`   // 例句中實際出現的形式（可能含 -s、-ed、-ing 變化）
+	Source      string `json:"source"`   // 例句來源：dictionary 或 wordlist
+}
+
+// GetClozeQuiz 以例句產生填空題
+// 優先使用字典 API 的例句，沒有時改用題庫中的 example 欄位，兩者皆無則跳過該單字
+func GetClozeQuiz(c *gin.Context) {
+	category := c.Param("category")
+	filename := c.Param("filename")
+	lastWord := c.Query("last")
+
+	limit, err := strconv.Atoi(c.Param("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10 // 預設返回 10 題
+	}
+
+	// 讀取題庫內容
+	filePath := filepath.Join(wordlistPath, category, filename+".txt")
+	words, err := parseWordlistFile(filePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法解析題庫"})
+		return
+	}
+
+	words = filterWords(words, parseWordFilter(c))
+	if len(words) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "題庫為空，無可用單字"})
+		return
+	}
+
+	// 讀取用戶學習進度
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "無法讀取用戶學習進度"})
+		return
+	}
+	userData.applySharedWeights(category, filename)
+	userProgress := userData.Progress
+	EnsureCategoryAndFilename(userProgress, category, filename)
+
+	// ✅ 依權重排序所有單字，每次只查詢還缺的題數（同時查詢，見 batchLookup），
+	// 有單字沒有例句時才往下一批候選單字補題，避免冷快取時逐一查詢整份題庫
+	candidates := selectWeightedWords(words, userProgress[category][filename], userData.WordStates[category][filename], lastWord, len(words))
+
+	lang := wordlistLanguage(category, filename)
+	questions := make([]ClozeQuestion, 0, limit)
+	skipped := []string{}
+	for start := 0; start < len(candidates) && len(questions) < limit; {
+		batch := candidates[start:min(len(candidates), start+limit-len(questions))]
+		start += len(batch)
+
+		terms := make([]string, len(batch))
+		for i, word := range batch {
+			terms[i] = word["word"]
+		}
+		for i, result := range batchLookup(terms, lang) {
+			question, ok := buildClozeQuestion(batch[i], lang, result.Data, result.Err)
+			if !ok {
+				skipped = append(skipped, batch[i]["word"])
+				continue
+			}
+			questions = append(questions, question)
+		}
+	}
+
+	if len(questions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "沒有可用的例句可以出題", "skipped": skipped})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"questions": questions, "skipped": skipped})
+}
+
+// buildClozeQuestion 以字典查詢結果（lookupErr 不為 nil 時代表查詢失敗）為單字尋找含有該字的例句並挖空
+func buildClozeQuestion(word map[string]string, lang string, data FilteredWordData, lookupErr error) (ClozeQuestion, bool) {
+	question := ClozeQuestion{
+		Word:        word["word"],
+		Translation: word["translation"],
+		Type:        word["type"],
+	}
+
+	// 1️⃣ 字典 API 的例句（查詢失敗時直接改用題庫例句）
+	if lookupErr == nil {
+		for _, m := range data.Meanings {
+			if sentence, answer, ok := blankOutWord(m.Example, word["word"], lang); ok {
+				question.Sentence, question.Answer, question.Source = sentence, answer, "dictionary"
+				return question, true
+			}
+		}
+	}
+
+	// 2️⃣ 題庫提供的例句
+	if sentence, answer, ok := blankOutWord(word["example"], word["word"], lang); ok {
+		question.Sentence, question.Answer, question.Source = sentence, answer, "wordlist"
+		return question, true
+	}
+
+	return question, false
+}
+
+// blankOutWord 將例句中第一個出現的目標單字替換為空格；英文會一併比對簡單的字尾變化
+func blankOutWord(sentence, word, lang string) (string, string, bool) {
+	if strings.TrimSpace(sentence) == "" || strings.TrimSpace(word) == "" {
+		return "", "", false
+	}
+
+	re := clozePattern(word, lang == defaultDictionaryLang)
+	loc := re.FindStringIndex(sentence)
+	if loc == nil {
+		return "", "", false
+	}
+
+	answer := sentence[loc[0]:loc[1]]
+	return sentence[:loc[0]] + clozeBlank + sentence[loc[1]:], answer, true
+}
+
+// clozePattern 建立比對單字及其變化形的正規表示式
+// 片語只變化第一個字（例如 turn left → turned left），其餘字詞照原樣比對
+func clozePattern(word string, inflect bool) *regexp.Regexp {
+	tokens := strings.Fields(strings.ToLower(word))
+
+	variants := []string{tokens[0]}
+	if inflect {
+		variants = inflections(tokens[0])
+	}
+	var forms []string
+	for _, form := range variants {
+		forms = append(forms, regexp.QuoteMeta(form))
+	}
+
+	pattern := "(?:" + strings.Join(forms, "|") + ")"
+	for _, token := range tokens[1:] {
+		pattern += `\s+` + regexp.QuoteMeta(token)
+	}
+
+	// Go 的 \b 只認得 ASCII 字元，非英文（例如日文、含重音的法文）改用單純的字串比對
+	if !inflect {
+		return regexp.MustCompile(`(?i)` + pattern)
+	}
+	return regexp.MustCompile(`(?i)\b` + pattern + `\b`)
+}
+
+// inflections 產生單字常見的字尾變化：-s / -es / -ed / -ing，
+// 並處理字尾 e、子音 + y 以及短母音重複字尾子音（stop → stopped）的情況
+func inflections(word string) []string {
+	forms := []string{word, word + "s", word + "es", word + "ed", word + "ing"}
+
+	n := len(word)
+	if n < 2 {
+		return forms
+	}
+
+	last := word[n-1]
+	switch {
+	case last == 'e':
+		forms = append(forms, word+"d", word[:n-1]+"ing")
+	case last == 'y' && !isVowel(word[n-2]):
+		forms = append(forms, word[:n-1]+"ies", word[:n-1]+"ied")
+	case n >= 3 && !isVowel(last) && isVowel(word[n-2]) && !isVowel(word[n-3]) && !strings.ContainsRune("wxy", rune(last)):
+		forms = append(forms, word+string(last)+"ed", word+string(last)+"ing")
+	}
+
+	// 較長的形式放前面，避免 regexp 只比對到較短的前綴
+	for i := 1; i < len(forms); i++ {
+		for j := i; j > 0 && len(forms[j]) > len(forms[j-1]); j-- {
+			forms[j], forms[j-1] = forms[j-1], forms[j]
+		}
+	}
+	return forms
+}
+
+// isVowel 判斷字元是否為英文母音
+func isVowel(ch byte) bool {
+	return strings.IndexByte("aeiou", ch) >= 0
+}
@@ -0,0 +1,207 @@
+package GoApiFunc
+
+import (
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quizModeDictation 為聽寫模式，單字權重與作答統計記錄在 DictationProgress、DictationStats，與拼字練習分開計算
+const quizModeDictation = "dictation"
+
+// DictationQuestion 定義一題聽寫題：播放發音，使用者輸入聽到的單字
+type DictationQuestion struct {
+	Word        string `json:"word"` // 正確答案
+	Translation string `json:"translation"`
+	Type        string `json:"type"`
+	Audio       string `json:"audio,omitempty"` // 本機音檔網址（見 audio_cache.go）
+	HasAudio    bool   `json:"hasAudio"`
+}
+
+// GetDictationQuiz 產生聽寫測驗：題目為單字的發音音檔而不是中文翻譯
+// 沒有音檔的單字預設跳過並列在 missing；帶 ?includeMissing=true 時改為保留並標示 hasAudio: false
+// 回傳中的 eligible / total 為題庫（套用篩選後）中有音檔的單字數與總數；
+// 只有出題時輪到的單字才會查詢字典，其餘沒有補充資料或字典快取的單字列為 unchecked（執行補充資料工作後即可得到完整數字）
+func GetDictationQuiz(c *gin.Context) {
+	category := c.Param("category")
+	filename := c.Param("filename")
+	lastWord := c.Query("last")
+	includeMissing, _ := strconv.ParseBool(c.Query("includeMissing"))
+
+	limit, err := strconv.Atoi(c.Param("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10 // 預設返回 10 題
+	}
+
+	// 讀取題庫內容
+	filePath := filepath.Join(wordlistPath, category, filename+".txt")
+	words, err := parseWordlistFile(filePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法解析題庫"})
+		return
+	}
+
+	words = filterWords(words, parseWordFilter(c))
+	if len(words) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "題庫為空，無可用單字"})
+		return
+	}
+
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "無法讀取用戶學習進度"})
+		return
+	}
+
+	lang := wordlistLanguage(category, filename)
+	audio := knownWordlistAudio(category, filename, words, lang)
+
+	// ✅ 依聽寫進度的權重排序，逐一加入有音檔的單字，直到湊滿 limit 題；
+	// 還不知道有沒有音檔的單字輪到時才查詢字典，一次只查詢補滿題數所需的數量
+	userData.applySharedWeights(category, filename)
+	candidates := selectWeightedWords(words, userData.DictationProgress[category][filename], userData.WordStates[category][filename], lastWord, len(words))
+	questions := make([]DictationQuestion, 0, limit)
+	for i, word := range candidates {
+		if len(questions) >= limit {
+			break
+		}
+		if _, checked := audio[word["word"]]; !checked {
+			lookupCandidateAudio(audio, candidates[i:], limit-len(questions), lang)
+		}
+		question := DictationQuestion{
+			Word:        word["word"],
+			Translation: word["translation"],
+			Type:        word["type"],
+			HasAudio:    audio[word["word"]] != "",
+		}
+		if !question.HasAudio && !includeMissing {
+			continue
+		}
+		if question.HasAudio {
+			question.Audio = localAudioURL(word["word"], lang)
+		}
+		questions = append(questions, question)
+	}
+
+	missing := []string{}
+	eligible := 0
+	for _, word := range words {
+		url, checked := audio[word["word"]]
+		switch {
+		case !checked:
+		case url == "":
+			missing = append(missing, word["word"])
+		default:
+			eligible++
+		}
+	}
+	unchecked := len(words) - eligible - len(missing)
+
+	if len(questions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "題庫中沒有可用的發音音檔", "total": len(words), "eligible": 0, "missing": missing, "unchecked": unchecked})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"questions": questions,
+		"lang":      lang,
+		"total":     len(words),
+		"eligible":  eligible,
+		"missing":   missing,
+		"unchecked": unchecked,
+	})
+}
+
+// knownWordlistAudio 回傳不需要查詢字典就能得知的音檔網址：題庫補充資料（見 dictionary_batch.go）與字典快取
+// 結果中的空字串代表確定沒有音檔，不在結果中的單字則還不知道
+func knownWordlistAudio(category, filename string, words []map[string]string, lang string) map[string]string {
+	audio := make(map[string]string, len(words))
+	enriched, _ := loadEnrichedWordlist(category, filename)
+
+	for _, word := range words {
+		if enriched != nil {
+			if entry, found := enriched.Entries[word["word"]]; found {
+				audio[word["word"]] = entry.Audio
+				continue
+			}
+		}
+		if cached, found := wordCache.Load(dictionaryCacheKey(word["word"], lang)); found {
+			if cached.NotFound {
+				audio[word["word"]] = ""
+			} else if cached.Data != nil {
+				audio[word["word"]] = cached.Data.Audio
+			}
+		}
+	}
+	return audio
+}
+
+// lookupCandidateAudio 從候選單字中依序挑出最多 count 個還不知道有沒有音檔的單字，批次查詢後寫入 audio
+func lookupCandidateAudio(audio map[string]string, candidates []map[string]string, count int, lang string) {
+	pending := make([]string, 0, count)
+	for _, word := range candidates {
+		if len(pending) >= count {
+			break
+		}
+		if _, checked := audio[word["word"]]; !checked && !slices.Contains(pending, word["word"]) {
+			pending = append(pending, word["word"])
+		}
+	}
+
+	for _, result := range batchLookup(pending, lang) {
+		audio[result.Word] = ""
+		if result.Err == nil {
+			audio[result.Word] = result.Data.Audio
+		}
+	}
+}
+
+// modeProgress 回傳測驗模式對應的題庫進度：聽寫使用 DictationProgress，其他模式共用 Progress
+func modeProgress(userData *UserData, mode, category, filename string) map[string]float64 {
+	userData.applySharedWeights(category, filename)
+	if mode == quizModeDictation {
+		if userData.DictationProgress == nil {
+			userData.DictationProgress = make(map[string]map[string]map[string]float64)
+		}
+		EnsureCategoryAndFilename(userData.DictationProgress, category, filename)
+		return userData.DictationProgress[category][filename]
+	}
+
+	if userData.Progress == nil {
+		userData.Progress = make(map[string]map[string]map[string]float64)
+	}
+	EnsureCategoryAndFilename(userData.Progress, category, filename)
+	return userData.Progress[category][filename]
+}
+
+// modeStatsRoot 回傳測驗模式對應的作答統計：聽寫使用 DictationStats，其他模式共用 WordStats
+func modeStatsRoot(userData *UserData, mode string) map[string]map[string]map[string]*WordStat {
+	if mode == quizModeDictation {
+		if userData.DictationStats == nil {
+			userData.DictationStats = make(map[string]map[string]map[string]*WordStat)
+		}
+		return userData.DictationStats
+	}
+
+	if userData.WordStats == nil {
+		userData.WordStats = make(map[string]map[string]map[string]*WordStat)
+	}
+	return userData.WordStats
+}
+
+// modeStats 回傳測驗模式對應的題庫作答統計（見 modeStatsRoot）
+func modeStats(userData *UserData, mode, category, filename string) map[string]*WordStat {
+	return ensureWordStats(modeStatsRoot(userData, mode), category, filename)
+}
+
+// wordPracticed 判斷單字是否已有拼字或聽寫的練習紀錄
+func wordPracticed(userData *UserData, category, filename, word string) bool {
+	if _, found := userData.Progress[category][filename][word]; found {
+		return true
+	}
+	_, found := userData.DictationProgress[category][filename][word]
+	return found
+}
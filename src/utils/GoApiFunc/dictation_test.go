@@ -0,0 +1,108 @@
+package GoApiFunc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// countingProvider 為測試用的字典來源，記錄查詢過的單字；noAudio 中的單字沒有音檔
+type countingProvider struct {
+	mu      sync.Mutex
+	lookups []string
+	noAudio map[string]bool
+}
+
+func (p *countingProvider) Name() string              { return "counting" }
+func (p *countingProvider) Supports(lang string) bool { return lang == defaultDictionaryLang }
+
+func (p *countingProvider) Lookup(word, lang string) ([]WordData, error) {
+	p.mu.Lock()
+	p.lookups = append(p.lookups, word)
+	p.mu.Unlock()
+	entry := WordData{Word: word}
+	if !p.noAudio[word] {
+		entry.Phonetics = []Phonetic{{Audio: "https://audio.example/" + word + ".mp3"}}
+	}
+	return []WordData{entry}, nil
+}
+
+// useTestDictionary 以測試用的字典來源與空白快取取代全域設定
+func useTestDictionary(t *testing.T, provider DictionaryProvider) {
+	t.Helper()
+	previousProvider, previousCache := dictionaryProvider, wordCache
+	dictionaryProvider = provider
+	wordCache = newDictionaryCache(filepath.Join(t.TempDir(), "cache.json"), time.Hour, time.Minute, 1000)
+	t.Cleanup(func() { dictionaryProvider, wordCache = previousProvider, previousCache })
+}
+
+func TestDictationQuizLooksUpOnlySelectedWords(t *testing.T) {
+	useTempDataDir(t)
+	resetEnrichedIndex(t)
+	provider := &countingProvider{noAudio: map[string]bool{"word01": true, "word05": true}}
+	useTestDictionary(t, provider)
+
+	lines := make([]string, 0, 30)
+	for i := 0; i < 30; i++ {
+		word := fmt.Sprintf("word%02d", i)
+		lines = append(lines, word+",翻譯,noun")
+	}
+	writeWordlist(t, "dictation", "lazy", lines...)
+	if err := writeEnrichedWordlist(&EnrichedWordlist{Category: "dictation", Filename: "lazy", Lang: "en", Entries: map[string]FilteredWordData{
+		"word00": {Word: "word00", Audio: "https://audio.example/word00.mp3"},
+		"word01": {Word: "word01"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/dictation/:category/:filename/:limit", GetDictationQuiz)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dictation/dictation/lazy/3", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Questions []DictationQuestion `json:"questions"`
+		Total     int                 `json:"total"`
+		Eligible  int                 `json:"eligible"`
+		Missing   []string            `json:"missing"`
+		Unchecked int                 `json:"unchecked"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Questions) != 3 {
+		t.Fatalf("questions = %+v, want 3", response.Questions)
+	}
+	for _, question := range response.Questions {
+		if !question.HasAudio || provider.noAudio[question.Word] {
+			t.Errorf("question without audio: %+v", question)
+		}
+	}
+
+	// 只查詢補滿 3 題需要的單字（最多再加上沒有音檔的 word05），而不是整個題庫
+	provider.mu.Lock()
+	lookups := append([]string(nil), provider.lookups...)
+	provider.mu.Unlock()
+	if len(lookups) < 2 || len(lookups) > 4 {
+		t.Errorf("looked up %d words (%v), want only the words needed for 3 questions", len(lookups), lookups)
+	}
+	for _, word := range lookups {
+		if word == "word00" || word == "word01" {
+			t.Errorf("%s has enriched data and must not be looked up", word)
+		}
+	}
+	if response.Total != 30 || response.Eligible+len(response.Missing)+response.Unchecked != 30 || response.Unchecked < 24 {
+		t.Errorf("counts = total %d, eligible %d, missing %d, unchecked %d", response.Total, response.Eligible, len(response.Missing), response.Unchecked)
+	}
+}
@@ -2,13 +2,12 @@
 package GoApiFunc
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"net"
 	"net/http"
+	"regexp"
+	"strings"
 	"sync"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,68 +19,77 @@ type Phonetic struct {
 }
 
 type Definition struct {
-	Definition string `json:"definition"`
-	Example    string `json:"example,omitempty"`
+	Definition string   `json:"definition"`
+	Example    string   `json:"example,omitempty"`
+	Synonyms   []string `json:"synonyms,omitempty"`
+	Antonyms   []string `json:"antonyms,omitempty"`
 }
 
 type Meaning struct {
 	PartOfSpeech string       `json:"partOfSpeech"`
 	Definitions  []Definition `json:"definitions"`
+	Synonyms     []string     `json:"synonyms,omitempty"`
+	Antonyms     []string     `json:"antonyms,omitempty"`
 }
 
 type WordData struct {
-	Word      string     `json:"word"`
-	Phonetic  string     `json:"phonetic"`
-	Phonetics []Phonetic `json:"phonetics"`
-	Meanings  []Meaning  `json:"meanings"`
+	Word       string     `json:"word"`
+	Phonetic   string     `json:"phonetic"`
+	Phonetics  []Phonetic `json:"phonetics"`
+	Meanings   []Meaning  `json:"meanings"`
+	SourceURLs []string   `json:"sourceUrls,omitempty"`
 }
 
 // 過濾後的回傳格式
 type FilteredWordData struct {
-	Word     string            `json:"word"`
-	Phonetic string            `json:"phonetic"`
-	Audio    string            `json:"audio,omitempty"`
-	Meanings []FilteredMeaning `json:"meanings"`
+	Word       string            `json:"word"`
+	Phonetic   string            `json:"phonetic"`
+	Audio      string            `json:"audio,omitempty"`
+	LocalAudio string            `json:"localAudio,omitempty"` // 本機快取的音檔網址（見 audio_cache.go）
+	Meanings   []FilteredMeaning `json:"meanings"`
+	Partial    bool              `json:"partial,omitempty"` // 片語查無資料，改由逐字查詢組合而成
+	Missing    []string          `json:"missing,omitempty"` // 逐字查詢時查無資料的單字
 }
 
 type FilteredMeaning struct {
+	Word         string `json:"word,omitempty"` // 逐字查詢時標示此義項屬於哪個單字
 	PartOfSpeech string `json:"partOfSpeech"`
 	Definition   string `json:"definition"`
 	Example      string `json:"example,omitempty"`
 }
 
-// ✅ 使用 sync.Map 優化快取
-var wordCache sync.Map
+// ErrWordNotFound 表示字典來源查無此字（HTTP 404），此結果會被短暫快取
+var ErrWordNotFound = errors.New("word not found")
 
-// ✅ `FetchWordData` 直接檢查網路錯誤，移除 `isInternetAvailable`
-func FetchWordData(word string) ([]WordData, error) {
-	client := http.Client{Timeout: 5 * time.Second}
+// FetchWordData 透過目前設定的字典來源（見 dictionary_provider.go）取得指定語言的單字資料
+func FetchWordData(word, lang string) ([]WordData, error) {
+	return dictionaryProvider.Lookup(word, lang)
+}
 
-	url := fmt.Sprintf("https://api.dictionaryapi.dev/api/v2/entries/en/%s", word)
-	resp, err := client.Get(url)
-	if err != nil {
-		if _, ok := err.(net.Error); ok {
-			return nil, fmt.Errorf("network unreachable") // 直接判斷網路錯誤
-		}
-		return nil, fmt.Errorf("failed to fetch word data: %v", err)
-	}
-	defer resp.Body.Close()
+// langPattern 限制語言代碼格式（例如 en、ja、pt-br），避免被拼進網址或檔名時造成問題
+var langPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch word data: status %d", resp.StatusCode)
+// normalizeLang 將語言代碼轉為小寫，空值時使用英文；格式不正確時回傳 false
+func normalizeLang(lang string) (string, bool) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		return defaultDictionaryLang, true
 	}
+	return lang, langPattern.MatchString(lang)
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
-	}
+// dictionaryCacheKey 快取的 key 包含語言，避免不同語言的同形字互相覆蓋
+func dictionaryCacheKey(word, lang string) string {
+	return lang + ":" + normalizeLookupTerm(word)
+}
 
-	var wordData []WordData
-	if err = json.Unmarshal(body, &wordData); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON response: %v", err)
-	}
+// parentheticalPattern 比對題庫中的括號補充說明，例如 CEO (Chief Executive Officer)、蘋果（水果）
+var parentheticalPattern = regexp.MustCompile(`\s*[(（][^()（）]*[)）]`)
 
-	return wordData, nil
+// normalizeLookupTerm 將題庫中的單字轉為查詢用的字詞：移除括號說明、轉小寫並合併多餘空白
+func normalizeLookupTerm(word string) string {
+	word = parentheticalPattern.ReplaceAllString(word, " ")
+	return strings.Join(strings.Fields(strings.ToLower(word)), " ")
 }
 
 // ✅ 優化 `FilterWordData`，確保 `example` 只在有內容時加入
@@ -139,35 +147,202 @@ func FilterWordData(data []WordData) FilteredWordData {
 	}
 }
 
-// ✅ 精簡 `GetWordHandler`，移除不必要的鎖定邏輯
-func GetWordHandler(c *gin.Context) {
-	word := c.Param("word")
+// lookupCall 為一次進行中的字典查詢，同一個單字的並行請求會共用結果
+type lookupCall struct {
+	done chan struct{}
+	raw  []WordData
+	data FilteredWordData
+	err  error
+}
+
+// ✅ 進行中的查詢（key 與快取相同），避免前端預取時同一個字同時打好幾次 API
+var (
+	inflightLookups   = make(map[string]*lookupCall)
+	inflightLookupsMu sync.Mutex
+)
+
+// lookupWord 先從快取讀取單字資料，沒有時才請求 API 並寫入快取
+// 供 GetWordHandler 與各種測驗模式共用
+func lookupWord(word, lang string) (FilteredWordData, error) {
+	_, data, err := lookupWordEntries(word, lang)
+	return data, err
+}
+
+// lookupWordEntries 與 lookupWord 相同，但同時回傳完整的原始 WordData
+// 查詢前會先整理字詞（見 normalizeLookupTerm）；查詢失敗時先找題庫補充資料，片語查無資料時再改為逐字查詢並組合結果
+func lookupWordEntries(word, lang string) ([]WordData, FilteredWordData, error) {
+	term := normalizeLookupTerm(word)
+	if term == "" {
+		return nil, FilteredWordData{}, ErrWordNotFound
+	}
 
-	// ✅ 先從快取讀取
-	if cachedData, found := wordCache.Load(word); found {
+	var (
+		raw  []WordData
+		data FilteredWordData
+		err  = unsupportedLanguage(dictionaryProvider.Name(), lang)
+	)
+	if dictionaryProvider.Supports(lang) {
+		raw, data, err = lookupTerm(term, lang)
+		if err == nil {
+			return raw, data, nil
+		}
+	}
+	// ✅ 字典來源不支援、查不到或無法連線時，改用題庫補充資料（見 dictionary_batch.go）
+	if entry, found := lookupEnrichedEntry(term, lang); found {
+		fmt.Println("🟡 使用題庫補充資料:", term)
+		return enrichedRawData(entry), entry, nil
+	}
+	if errors.Is(err, ErrWordNotFound) && strings.Contains(term, " ") {
+		return lookupPhraseWords(term, lang)
+	}
+	return raw, data, err
+}
+
+// lookupTerm 查詢單一字詞（已整理過），同一個字詞同時只會有一個請求送往上游，其餘呼叫等待並共用結果
+// 舊版格式的快取（缺少同反義詞等欄位）會重新查詢，查詢失敗（例如離線）時仍沿用舊資料
+func lookupTerm(word, lang string) ([]WordData, FilteredWordData, error) {
+	// ✅ 先從快取讀取（包含短暫保留的「查無此字」結果）
+	key := dictionaryCacheKey(word, lang)
+	cached, found := wordCache.Load(key)
+	if found && !cached.NotFound && cached.Data == nil {
+		found = false // 缺少資料的快取（例如手動修改過的快取檔）視為沒有快取，重新查詢
+	}
+	stale := found && !cached.NotFound && cached.Version < dictionaryCacheVersion
+	if found && !stale {
 		fmt.Println("🟢 使用快取資料:", word)
-		c.JSON(http.StatusOK, cachedData.(FilteredWordData))
-		return
+		if cached.NotFound {
+			return nil, FilteredWordData{}, ErrWordNotFound
+		}
+		return cached.Raw, *cached.Data, nil
+	}
+
+	inflightLookupsMu.Lock()
+	if call, exists := inflightLookups[key]; exists {
+		inflightLookupsMu.Unlock()
+		<-call.done
+		return call.raw, call.data, call.err
+	}
+	call := &lookupCall{done: make(chan struct{})}
+	inflightLookups[key] = call
+	inflightLookupsMu.Unlock()
+
+	call.raw, call.data, call.err = fetchAndCache(key, word, lang)
+	if call.err != nil && stale && !errors.Is(call.err, ErrWordNotFound) {
+		call.raw, call.data, call.err = cached.Raw, *cached.Data, nil
 	}
 
+	inflightLookupsMu.Lock()
+	delete(inflightLookups, key)
+	inflightLookupsMu.Unlock()
+	close(call.done)
+
+	return call.raw, call.data, call.err
+}
+
+// fetchAndCache 請求字典來源並將結果（包含查無此字）寫入快取
+func fetchAndCache(key, word, lang string) ([]WordData, FilteredWordData, error) {
 	// ❌ 如果快取沒有，則請求 API
-	wordData, err := FetchWordData(word)
+	wordData, err := FetchWordData(word, lang)
 	if err != nil {
-		status := http.StatusInternalServerError
-		msg := "Failed to fetch word data"
-		if err.Error() == "network unreachable" {
-			status = http.StatusServiceUnavailable
-			msg = "Network Unreachable, please check your internet connection."
+		if errors.Is(err, ErrWordNotFound) {
+			wordCache.StoreNotFound(key)
 		}
-		c.JSON(status, gin.H{"error": msg})
-		return
+		return nil, FilteredWordData{}, err
 	}
 
 	filteredData := FilterWordData(wordData)
 
-	// ✅ 更新快取
-	wordCache.Store(word, filteredData)
+	// ✅ 更新快取（同時保留原始 WordData）
+	wordCache.Store(key, wordData, filteredData)
 
 	fmt.Println("🔵 請求 API 取得新資料:", word)
-	c.JSON(http.StatusOK, filteredData)
+	return wordData, filteredData, nil
+}
+
+// lookupPhraseWords 逐字查詢片語中的每個單字，組合成標示為 partial 的結果
+// 每個義項會標示所屬單字；所有單字都查無資料時回傳 ErrWordNotFound，其他錯誤直接回傳
+func lookupPhraseWords(phrase, lang string) ([]WordData, FilteredWordData, error) {
+	combined := FilteredWordData{Word: phrase, Partial: true, Meanings: []FilteredMeaning{}}
+	var raw []WordData
+	var phonetics []string
+
+	for _, part := range uniqueStrings(strings.Fields(phrase)) {
+		partRaw, partData, err := lookupTerm(part, lang)
+		if errors.Is(err, ErrWordNotFound) {
+			combined.Missing = append(combined.Missing, part)
+			continue
+		}
+		if err != nil {
+			return nil, FilteredWordData{}, err
+		}
+
+		raw = append(raw, partRaw...)
+		if partData.Phonetic != "" {
+			phonetics = append(phonetics, partData.Phonetic)
+		}
+		for _, m := range partData.Meanings {
+			m.Word = part
+			combined.Meanings = append(combined.Meanings, m)
+		}
+	}
+
+	if len(raw) == 0 {
+		return nil, FilteredWordData{}, ErrWordNotFound
+	}
+	combined.Phonetic = strings.Join(phonetics, " ")
+
+	fmt.Println("🟡 片語查無資料，改用逐字查詢:", phrase)
+	return raw, combined, nil
+}
+
+// dictionaryErrorResponse 將字典查詢錯誤對應到 HTTP 狀態碼與錯誤訊息
+func dictionaryErrorResponse(err error, word, lang string) (int, string) {
+	var upstream *UpstreamError
+	switch {
+	case errors.Is(err, ErrWordNotFound):
+		return http.StatusNotFound, fmt.Sprintf("Word %q not found", word)
+	case errors.Is(err, ErrUnsupportedLanguage):
+		return http.StatusBadRequest, fmt.Sprintf("Language %q is not supported by the configured dictionary providers (%s)", lang, dictionaryProvider.Name())
+	case errors.Is(err, ErrNetworkUnreachable):
+		return http.StatusServiceUnavailable, "Network Unreachable, please check your internet connection."
+	case errors.As(err, &upstream) && upstream.Retryable():
+		return http.StatusServiceUnavailable, "Dictionary service is busy, please try again later."
+	case errors.As(err, &upstream):
+		return http.StatusBadGateway, "Dictionary service returned an unexpected response"
+	default:
+		return http.StatusInternalServerError, "Failed to fetch word data"
+	}
+}
+
+// ✅ 精簡 `GetWordHandler`，移除不必要的鎖定邏輯
+// 透過 ?lang= 指定語言（預設 en）；?detail=full 時回傳包含所有詞條、義項與同反義詞的完整資料
+func GetWordHandler(c *gin.Context) {
+	word := c.Param("word")
+	lang, ok := normalizeLang(c.Query("lang"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid language code %q", c.Query("lang"))})
+		return
+	}
+
+	rawData, filteredData, err := lookupWordEntries(word, lang)
+	if err != nil {
+		status, msg := dictionaryErrorResponse(err, word, lang)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	if c.Query("detail") == "full" {
+		c.JSON(http.StatusOK, buildFullResult(word, rawData, filteredData))
+		return
+	}
+
+	c.JSON(http.StatusOK, withLocalAudio(filteredData, word, lang))
+}
+
+// withLocalAudio 有音檔時加上本機音檔網址，讓前端不必直接連線到外部音檔
+func withLocalAudio(data FilteredWordData, word, lang string) FilteredWordData {
+	if data.Audio != "" {
+		data.LocalAudio = localAudioURL(word, lang)
+	}
+	return data
 }
@@ -0,0 +1,28 @@
+package GoApiFunc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookupTermIgnoresCacheEntriesWithoutData(t *testing.T) {
+	provider := &countingProvider{}
+	useTestDictionary(t, provider)
+
+	for _, version := range []int{dictionaryCacheVersion, dictionaryCacheVersion - 1} {
+		provider.lookups = nil
+		key := dictionaryCacheKey("apple", defaultDictionaryLang)
+		wordCache.put(&DictionaryCacheEntry{Key: key, Version: version}, time.Hour)
+
+		_, data, err := lookupTerm("apple", defaultDictionaryLang)
+		if err != nil || data.Word != "apple" {
+			t.Fatalf("version %d: lookupTerm = %+v, %v", version, data, err)
+		}
+		if len(provider.lookups) != 1 {
+			t.Errorf("version %d: lookups = %v, want one fetch", version, provider.lookups)
+		}
+		if cached, found := wordCache.Load(key); !found || cached.Data == nil {
+			t.Errorf("version %d: cache entry was not replaced: %+v", version, cached)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package GoApiFunc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 批次查詢設定：
+// maxBatchWords：單次批次查詢的單字數上限
+// batchWorkers：同時處理的查詢數（實際對上游的請求數仍受字典來源限制）
+const (
+	maxBatchWords = 200
+	batchWorkers  = 8
+)
+
+// batchLookupResult 為批次查詢中單一單字的結果
+type batchLookupResult struct {
+	Word string
+	Raw  []WordData
+	Data FilteredWordData
+	Err  error
+}
+
+// batchLookup 以固定數量的 worker 查詢多個單字，回傳順序與輸入相同
+func batchLookup(words []string, lang string) []batchLookupResult {
+	results := make([]batchLookupResult, len(words))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < batchWorkers && w < len(words); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				raw, data, err := lookupWordEntries(words[i], lang)
+				results[i] = batchLookupResult{Word: words[i], Raw: raw, Data: data, Err: err}
+			}
+		}()
+	}
+	for i := range words {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// BatchLookupRequest 定義批次查詢的請求資料
+type BatchLookupRequest struct {
+	Words  []string `json:"words"`
+	Lang   string   `json:"lang"`
+	Detail string   `json:"detail"` // 設為 full 時回傳完整詞條
+}
+
+// BatchLookupHandler 一次查詢多個單字，每個單字各自回傳資料或錯誤
+func BatchLookupHandler(c *gin.Context) {
+	var req BatchLookupRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Words) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide a non-empty list of words"})
+		return
+	}
+	if len(req.Words) > maxBatchWords {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d words per batch", maxBatchWords)})
+		return
+	}
+	lang, ok := normalizeLang(req.Lang)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid language code %q", req.Lang)})
+		return
+	}
+
+	results := make([]gin.H, 0, len(req.Words))
+	failed := 0
+	for _, result := range batchLookup(req.Words, lang) {
+		item := gin.H{"word": result.Word}
+		switch {
+		case result.Err != nil:
+			status, msg := dictionaryErrorResponse(result.Err, result.Word, lang)
+			item["status"] = status
+			item["error"] = msg
+			failed++
+		case req.Detail == "full":
+			item["status"] = http.StatusOK
+			item["data"] = buildFullResult(result.Word, result.Raw, result.Data)
+		default:
+			item["status"] = http.StatusOK
+			item["data"] = withLocalAudio(result.Data, result.Word, lang)
+		}
+		results = append(results, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lang": lang, "results": results, "failed": failed})
+}
+
+// EnrichedWordlist 為題庫的字典補充資料，儲存在題庫旁的 <題庫>.enriched.json
+type EnrichedWordlist struct {
+	Category   string                      `json:"category"`
+	Filename   string                      `json:"filename"`
+	Lang       string                      `json:"lang"`
+	EnrichedAt time.Time                   `json:"enrichedAt"`
+	Entries    map[string]FilteredWordData `json:"entries"` // 單字 → 音標、音檔與例句
+	Errors     map[string]string           `json:"errors,omitempty"`
+}
+
+// EnrichJobStatus 為補充資料背景工作的進度
+type EnrichJobStatus struct {
+	Category   string     `json:"category"`
+	Filename   string     `json:"filename"`
+	State      string     `json:"state"` // running / done / failed
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Succeeded  int        `json:"succeeded"`
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ✅ 每個題庫同時只會有一個補充資料工作
+var (
+	enrichJobs   = make(map[string]*EnrichJobStatus)
+	enrichJobsMu sync.Mutex
+)
+
+// enrichedWordlistPath 回傳題庫補充資料的檔案路徑
+func enrichedWordlistPath(category, filename string) string {
+	return filepath.Join(wordlistPath, category, filename+".enriched.json")
+}
+
+// StartEnrichWordlist 啟動背景工作，預先查詢整個題庫的音標、音檔與例句並存檔
+func StartEnrichWordlist(c *gin.Context) {
+	category := c.Param("category")
+	filename := c.Param("filename")
+	if !validWordlistRef(category, filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "題庫名稱不合法"})
+		return
+	}
+
+	filePath := filepath.Join(wordlistPath, category, filename+".txt")
+	words, err := parseWordlistFile(filePath)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("題庫文件不存在: %s", filePath)})
+		return
+	}
+
+	key := filepath.Join(category, filename)
+	enrichJobsMu.Lock()
+	if job, exists := enrichJobs[key]; exists && job.State == "running" {
+		status := *job
+		enrichJobsMu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": "此題庫的補充資料工作仍在進行中", "status": status})
+		return
+	}
+	job := &EnrichJobStatus{
+		Category:  category,
+		Filename:  filename,
+		State:     "running",
+		Total:     len(words),
+		StartedAt: time.Now(),
+	}
+	enrichJobs[key] = job
+	status := *job
+	enrichJobsMu.Unlock()
+
+	go runEnrichJob(job, words, wordlistLanguage(category, filename))
+
+	c.JSON(http.StatusAccepted, status)
+}
+
+// GetEnrichWordlistStatus 查詢補充資料工作的進度
+func GetEnrichWordlistStatus(c *gin.Context) {
+	category := c.Param("category")
+	filename := c.Param("filename")
+	if !validWordlistRef(category, filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "題庫名稱不合法"})
+		return
+	}
+	key := filepath.Join(category, filename)
+
+	enrichJobsMu.Lock()
+	job, exists := enrichJobs[key]
+	var status EnrichJobStatus
+	if exists {
+		status = *job
+	}
+	enrichJobsMu.Unlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "沒有此題庫的補充資料工作"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// GetEnrichedWordlist 讀取已儲存的補充資料，讓前端在離線時也能顯示音標與例句
+func GetEnrichedWordlist(c *gin.Context) {
+	category := c.Param("category")
+	filename := c.Param("filename")
+	if !validWordlistRef(category, filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "題庫名稱不合法"})
+		return
+	}
+	enriched, err := loadEnrichedWordlist(category, filename)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "此題庫尚未補充字典資料"})
+		return
+	}
+	c.JSON(http.StatusOK, enriched)
+}
+
+// loadEnrichedWordlist 讀取題庫的補充資料檔
+func loadEnrichedWordlist(category, filename string) (*EnrichedWordlist, error) {
+	body, err := os.ReadFile(enrichedWordlistPath(category, filename))
+	if err != nil {
+		return nil, err
+	}
+	var enriched EnrichedWordlist
+	if err := json.Unmarshal(body, &enriched); err != nil {
+		return nil, err
+	}
+	return &enriched, nil
+}
+
+// runEnrichJob 分批查詢題庫中的所有單字並回報進度，完成後寫入補充資料檔
+func runEnrichJob(job *EnrichJobStatus, words []map[string]string, lang string) {
+	enriched := EnrichedWordlist{
+		Category: job.Category,
+		Filename: job.Filename,
+		Lang:     lang,
+		Entries:  make(map[string]FilteredWordData),
+		Errors:   make(map[string]string),
+	}
+
+	texts := make([]string, 0, len(words))
+	for _, word := range words {
+		texts = append(texts, word["word"])
+	}
+
+	// 以 batchWorkers 為單位分段查詢，讓進度能隨時更新
+	for start := 0; start < len(texts); start += batchWorkers {
+		end := start + batchWorkers
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		results := batchLookup(texts[start:end], lang)
+
+		enrichJobsMu.Lock()
+		for _, result := range results {
+			job.Processed++
+			if result.Err != nil {
+				_, msg := dictionaryErrorResponse(result.Err, result.Word, lang)
+				enriched.Errors[result.Word] = msg
+				job.Failed++
+				continue
+			}
+			enriched.Entries[result.Word] = result.Data
+			job.Succeeded++
+		}
+		enrichJobsMu.Unlock()
+	}
+
+	enriched.EnrichedAt = time.Now()
+	err := writeEnrichedWordlist(&enriched)
+	if err == nil {
+		updateEnrichedIndex(&enriched)
+	}
+
+	enrichJobsMu.Lock()
+	defer enrichJobsMu.Unlock()
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.State = "done"
+	if err != nil {
+		job.State = "failed"
+		job.Error = err.Error()
+	}
+}
+
+// writeEnrichedWordlist 寫入題庫的補充資料檔（先寫入暫存檔再改名，中途中斷不會留下不完整的檔案）
+func writeEnrichedWordlist(enriched *EnrichedWordlist) error {
+	return writeFileAtomic(enrichedWordlistPath(enriched.Category, enriched.Filename), enriched)
+}
+
+// ✅ 所有題庫補充資料的記憶體索引，字典來源無法使用（例如離線）時作為備援
+// 第一次查詢時讀取所有補充資料檔，之後只在補充資料工作寫入檔案時更新（見 updateEnrichedIndex）
+var (
+	enrichedIndexFiles map[string]*EnrichedWordlist           // 補充資料檔路徑 → 內容；nil 代表尚未讀取
+	enrichedIndexTerms map[string]map[string]FilteredWordData // 語言 → 整理過的字詞（見 normalizeLookupTerm）→ 補充資料
+	enrichedIndexMu    sync.Mutex
+)
+
+// lookupEnrichedEntry 從題庫補充資料的索引中尋找字詞
+func lookupEnrichedEntry(term, lang string) (FilteredWordData, bool) {
+	enrichedIndexMu.Lock()
+	defer enrichedIndexMu.Unlock()
+
+	if enrichedIndexFiles == nil {
+		loadEnrichedIndex()
+	}
+	entry, found := enrichedIndexTerms[lang][term]
+	return entry, found
+}
+
+// loadEnrichedIndex 讀取所有題庫的補充資料檔並建立索引（呼叫前需持有 enrichedIndexMu）
+func loadEnrichedIndex() {
+	enrichedIndexFiles = make(map[string]*EnrichedWordlist)
+	paths, _ := filepath.Glob(filepath.Join(wordlistPath, "*", "*.enriched.json"))
+	for _, path := range paths {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Println("❌ 無法讀取題庫補充資料:", path, err)
+			continue
+		}
+		var enriched EnrichedWordlist
+		if err := json.Unmarshal(body, &enriched); err != nil {
+			fmt.Println("❌ 無法讀取題庫補充資料:", path, err)
+			continue
+		}
+		enrichedIndexFiles[path] = &enriched
+	}
+	rebuildEnrichedTerms()
+}
+
+// updateEnrichedIndex 以剛寫入的補充資料更新索引；尚未讀取索引時留待第一次查詢再一併讀取
+func updateEnrichedIndex(enriched *EnrichedWordlist) {
+	enrichedIndexMu.Lock()
+	defer enrichedIndexMu.Unlock()
+
+	if enrichedIndexFiles == nil {
+		return
+	}
+	enrichedIndexFiles[enrichedWordlistPath(enriched.Category, enriched.Filename)] = enriched
+	rebuildEnrichedTerms()
+}
+
+// rebuildEnrichedTerms 依語言合併所有補充資料；同一個字詞出現在多個題庫時，以路徑排序在前的題庫為準（呼叫前需持有 enrichedIndexMu）
+func rebuildEnrichedTerms() {
+	paths := make([]string, 0, len(enrichedIndexFiles))
+	for path := range enrichedIndexFiles {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	enrichedIndexTerms = make(map[string]map[string]FilteredWordData)
+	for _, path := range paths {
+		enriched := enrichedIndexFiles[path]
+		lang := enriched.Lang
+		if lang == "" {
+			lang = defaultDictionaryLang
+		}
+		if enrichedIndexTerms[lang] == nil {
+			enrichedIndexTerms[lang] = make(map[string]FilteredWordData)
+		}
+		for word, entry := range enriched.Entries {
+			term := normalizeLookupTerm(word)
+			if _, exists := enrichedIndexTerms[lang][term]; term != "" && !exists {
+				enrichedIndexTerms[lang][term] = entry
+			}
+		}
+	}
+}
+
+// enrichedRawData 將補充資料轉回 WordData，讓需要完整格式的呼叫端（例如 detail=full）也能使用
+func enrichedRawData(data FilteredWordData) []WordData {
+	entry := WordData{Word: data.Word, Phonetic: data.Phonetic}
+	if data.Phonetic != "" || data.Audio != "" {
+		entry.Phonetics = []Phonetic{{Text: data.Phonetic, Audio: data.Audio}}
+	}
+	for _, meaning := range data.Meanings {
+		entry.Meanings = append(entry.Meanings, Meaning{
+			PartOfSpeech: meaning.PartOfSpeech,
+			Definitions:  []Definition{{Definition: meaning.Definition, Example: meaning.Example}},
+		})
+	}
+	return []WordData{entry}
+}
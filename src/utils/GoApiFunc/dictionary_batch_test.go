@@ -0,0 +1,83 @@
+package GoApiFunc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEnrichRoutesRejectUnsafeNames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/enrich/:category/:filename", StartEnrichWordlist)
+	r.GET("/enrich/:category/:filename", GetEnrichWordlistStatus)
+	r.GET("/enriched/:category/:filename", GetEnrichedWordlist)
+
+	requests := []struct{ method, path string }{
+		{http.MethodPost, "/enrich/../secret"},
+		{http.MethodPost, "/enrich/daily/.."},
+		{http.MethodGet, "/enrich/../secret"},
+		{http.MethodGet, "/enriched/../secret"},
+		{http.MethodGet, "/enriched/daily/..%5Cuser"},
+	}
+	for _, req := range requests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(req.method, req.path, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s = %d, want 400", req.method, req.path, w.Code)
+		}
+	}
+}
+
+// resetEnrichedIndex 清除補充資料索引，讓下一次查詢重新讀取暫存目錄中的檔案
+func resetEnrichedIndex(t *testing.T) {
+	t.Helper()
+	enrichedIndexMu.Lock()
+	enrichedIndexFiles = nil
+	enrichedIndexMu.Unlock()
+	t.Cleanup(func() {
+		enrichedIndexMu.Lock()
+		enrichedIndexFiles = nil
+		enrichedIndexMu.Unlock()
+	})
+}
+
+func TestLookupEnrichedEntry(t *testing.T) {
+	useTempDataDir(t)
+	resetEnrichedIndex(t)
+
+	daily := &EnrichedWordlist{Category: "daily", Filename: "basic", Lang: "en", Entries: map[string]FilteredWordData{
+		"Apple":     {Word: "apple", Phonetic: "/ˈæp.əl/"},
+		"turn left": {Word: "turn left"},
+	}}
+	if err := writeEnrichedWordlist(daily); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry, found := lookupEnrichedEntry(normalizeLookupTerm("apple"), "en"); !found || entry.Phonetic != "/ˈæp.əl/" {
+		t.Errorf("apple = %+v, %v", entry, found)
+	}
+	if _, found := lookupEnrichedEntry(normalizeLookupTerm("apple"), "fr"); found {
+		t.Error("entries must only match their own language")
+	}
+	if _, found := lookupEnrichedEntry(normalizeLookupTerm("banana"), "en"); found {
+		t.Error("banana should not be found yet")
+	}
+
+	// 補充資料工作寫入新的檔案後，不需重新掃描目錄就能查到
+	fruit := &EnrichedWordlist{Category: "daily", Filename: "fruit", Lang: "en", Entries: map[string]FilteredWordData{
+		"banana": {Word: "banana"},
+	}}
+	if err := writeEnrichedWordlist(fruit); err != nil {
+		t.Fatal(err)
+	}
+	updateEnrichedIndex(fruit)
+	if entry, found := lookupEnrichedEntry(normalizeLookupTerm("banana"), "en"); !found || entry.Word != "banana" {
+		t.Errorf("banana = %+v, %v", entry, found)
+	}
+	if _, found := lookupEnrichedEntry(normalizeLookupTerm("turn left"), "en"); !found {
+		t.Error("entries of other lists must stay indexed")
+	}
+}
@@ -0,0 +1,387 @@
+package GoApiFunc
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 字典快取的預設設定，可透過環境變數調整：
+// LEXIQUEST_DICT_CACHE_TTL：查詢成功的資料保留多久（例如 720h）
+// LEXIQUEST_DICT_NEGATIVE_TTL：查無此字的結果保留多久（例如 10m）
+// LEXIQUEST_DICT_CACHE_SIZE：快取最多保留幾筆，超過時淘汰最久未使用的資料
+// dictionaryCacheFlushDelay：快取變更後延遲多久才寫入磁碟，期間的變更合併為一次寫入
+// dictionaryCacheVersion：快取資料格式版本，WordData 增加欄位時調高，舊版的資料會重新查詢
+// （版本 2 起 Raw 包含同反義詞，見 dictionary_full.go）
+const (
+	dictionaryCacheVersion    = 2
+	dictionaryCachePath       = "./data/cache/dictionary.json"
+	defaultDictionaryTTL      = 30 * 24 * time.Hour
+	defaultDictionaryNegTTL   = 10 * time.Minute
+	defaultDictionaryCacheMax = 5000
+	dictionaryCacheFlushDelay = 2 * time.Second
+)
+
+// DictionaryCacheEntry 為快取中的一筆字典資料
+type DictionaryCacheEntry struct {
+	Key        string            `json:"key"`
+	Data       *FilteredWordData `json:"data,omitempty"`
+	Raw        []WordData        `json:"raw,omitempty"`
+	NotFound   bool              `json:"notFound,omitempty"` // 查無此字的負面快取
+	Version    int               `json:"version,omitempty"`  // 寫入時的 dictionaryCacheVersion，舊資料沒有此欄位
+	FetchedAt  time.Time         `json:"fetchedAt"`
+	ExpiresAt  time.Time         `json:"expiresAt"`
+	LastAccess time.Time         `json:"lastAccess"`
+}
+
+// dictionaryCache 是寫入 data/ 的 LRU 字典快取，重啟後仍可沿用
+type dictionaryCache struct {
+	mu          sync.Mutex
+	path        string
+	ttl         time.Duration
+	negativeTTL time.Duration
+	maxEntries  int
+	loaded      bool
+	entries     map[string]*list.Element // key → LRU 節點
+	order       *list.List               // 最前面為最近使用
+	hits        int
+	misses      int
+
+	dirty      bool        // 有尚未寫入磁碟的變更
+	flushTimer *time.Timer // 延遲寫入的計時器，dirty 時才存在
+	flushMu    sync.Mutex  // 確保同時只有一個寫入，寫檔時不持有 mu，查詢不會被磁碟 I/O 擋住
+}
+
+// ✅ 全域字典快取，第一次使用時才從磁碟載入
+var wordCache = newDictionaryCache(
+	dictionaryCachePath,
+	envDuration("LEXIQUEST_DICT_CACHE_TTL", defaultDictionaryTTL),
+	envDuration("LEXIQUEST_DICT_NEGATIVE_TTL", defaultDictionaryNegTTL),
+	envInt("LEXIQUEST_DICT_CACHE_SIZE", defaultDictionaryCacheMax),
+)
+
+func newDictionaryCache(path string, ttl, negativeTTL time.Duration, maxEntries int) *dictionaryCache {
+	return &dictionaryCache{
+		path:        path,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		maxEntries:  maxEntries,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// Load 取得未過期的快取資料，過期的資料會直接移除
+func (dc *dictionaryCache) Load(key string) (DictionaryCacheEntry, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.ensureLoaded()
+
+	elem, found := dc.entries[key]
+	if !found {
+		dc.misses++
+		return DictionaryCacheEntry{}, false
+	}
+
+	entry := elem.Value.(*DictionaryCacheEntry)
+	if time.Now().After(entry.ExpiresAt) {
+		dc.order.Remove(elem)
+		delete(dc.entries, key)
+		dc.misses++
+		return DictionaryCacheEntry{}, false
+	}
+
+	entry.LastAccess = time.Now()
+	dc.order.MoveToFront(elem)
+	dc.hits++
+	return *entry, true
+}
+
+// Store 寫入查詢成功的資料（同時保留原始 API 回應）
+func (dc *dictionaryCache) Store(key string, raw []WordData, data FilteredWordData) {
+	dc.put(&DictionaryCacheEntry{Key: key, Raw: raw, Data: &data, Version: dictionaryCacheVersion}, dc.ttl)
+}
+
+// StoreNotFound 寫入查無此字的結果，避免拼錯的字每次都請求 API
+func (dc *dictionaryCache) StoreNotFound(key string) {
+	dc.put(&DictionaryCacheEntry{Key: key, NotFound: true}, dc.negativeTTL)
+}
+
+func (dc *dictionaryCache) put(entry *DictionaryCacheEntry, ttl time.Duration) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.ensureLoaded()
+
+	now := time.Now()
+	entry.FetchedAt = now
+	entry.ExpiresAt = now.Add(ttl)
+	entry.LastAccess = now
+
+	if elem, found := dc.entries[entry.Key]; found {
+		elem.Value = entry
+		dc.order.MoveToFront(elem)
+	} else {
+		dc.entries[entry.Key] = dc.order.PushFront(entry)
+	}
+
+	// ✅ 超過上限時淘汰最久未使用的資料
+	for dc.maxEntries > 0 && dc.order.Len() > dc.maxEntries {
+		oldest := dc.order.Back()
+		dc.order.Remove(oldest)
+		delete(dc.entries, oldest.Value.(*DictionaryCacheEntry).Key)
+	}
+
+	dc.markDirty()
+}
+
+// Delete 移除單筆快取，回傳是否存在
+func (dc *dictionaryCache) Delete(key string) bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.ensureLoaded()
+
+	elem, found := dc.entries[key]
+	if !found {
+		return false
+	}
+	dc.order.Remove(elem)
+	delete(dc.entries, key)
+	dc.markDirty()
+	return true
+}
+
+// Purge 清除快取；expiredOnly 為 true 時只清除已過期的資料，回傳移除的筆數
+func (dc *dictionaryCache) Purge(expiredOnly bool) int {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.ensureLoaded()
+
+	now := time.Now()
+	removed := 0
+	for key, elem := range dc.entries {
+		if expiredOnly && !now.After(elem.Value.(*DictionaryCacheEntry).ExpiresAt) {
+			continue
+		}
+		dc.order.Remove(elem)
+		delete(dc.entries, key)
+		removed++
+	}
+	if removed > 0 {
+		dc.markDirty()
+	}
+	return removed
+}
+
+// Entries 依最近使用順序回傳所有快取資料的複本
+func (dc *dictionaryCache) Entries() []DictionaryCacheEntry {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.ensureLoaded()
+
+	entries := make([]DictionaryCacheEntry, 0, dc.order.Len())
+	for elem := dc.order.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, *elem.Value.(*DictionaryCacheEntry))
+	}
+	return entries
+}
+
+// ensureLoaded 第一次使用時從磁碟讀取快取（呼叫前需持有 dc.mu）
+func (dc *dictionaryCache) ensureLoaded() {
+	if dc.loaded {
+		return
+	}
+	dc.loaded = true
+
+	file, err := os.Open(dc.path)
+	if err != nil {
+		return // 檔案不存在時從空快取開始
+	}
+	defer file.Close()
+
+	var stored []*DictionaryCacheEntry
+	if err := json.NewDecoder(file).Decode(&stored); err != nil {
+		fmt.Println("⚠️ 無法讀取字典快取，將重新建立:", err)
+		return
+	}
+
+	// 依最後使用時間排序，還原 LRU 順序
+	sort.Slice(stored, func(i, j int) bool { return stored[i].LastAccess.After(stored[j].LastAccess) })
+	now := time.Now()
+	for _, entry := range stored {
+		if entry.Key == "" || now.After(entry.ExpiresAt) {
+			continue
+		}
+		if _, dup := dc.entries[entry.Key]; dup {
+			continue
+		}
+		dc.entries[entry.Key] = dc.order.PushBack(entry)
+	}
+}
+
+// markDirty 標記快取有變更，並在 dictionaryCacheFlushDelay 後寫入磁碟（呼叫前需持有 dc.mu）
+func (dc *dictionaryCache) markDirty() {
+	dc.dirty = true
+	if dc.flushTimer == nil {
+		dc.flushTimer = time.AfterFunc(dictionaryCacheFlushDelay, func() { dc.Flush() })
+	}
+}
+
+// Flush 立即將尚未寫入的變更寫入磁碟；在持有 dc.mu 時只複製條目，序列化與寫檔都在鎖外進行
+func (dc *dictionaryCache) Flush() error {
+	dc.flushMu.Lock()
+	defer dc.flushMu.Unlock()
+
+	dc.mu.Lock()
+	if dc.flushTimer != nil {
+		dc.flushTimer.Stop()
+		dc.flushTimer = nil
+	}
+	if !dc.dirty {
+		dc.mu.Unlock()
+		return nil
+	}
+	dc.dirty = false
+	entries := make([]DictionaryCacheEntry, 0, dc.order.Len())
+	for elem := dc.order.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, *elem.Value.(*DictionaryCacheEntry))
+	}
+	dc.mu.Unlock()
+
+	if err := writeFileAtomic(dc.path, entries); err != nil {
+		fmt.Println("❌ 無法寫入字典快取:", err)
+		// 寫入失敗時保留變更，稍後再試
+		dc.mu.Lock()
+		dc.markDirty()
+		dc.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// FlushDictionaryCache 在伺服器關閉前寫入尚未儲存的字典快取
+func FlushDictionaryCache() error {
+	return wordCache.Flush()
+}
+
+// writeFileAtomic 將資料序列化為 JSON 後先寫入暫存檔並同步到磁碟，再改名取代原檔，
+// 程式中途結束時原檔不會被截斷
+func writeFileAtomic(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // 改名成功後暫存檔已不存在，Remove 只會回傳錯誤
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// GetDictionaryCache 檢視字典快取：不帶參數時回傳統計與所有條目摘要，
+// 帶 ?word=（可搭配 ?lang=）時回傳該筆完整資料
+func GetDictionaryCache(c *gin.Context) {
+	if word := c.Query("word"); word != "" {
+		lang, _ := normalizeLang(c.Query("lang"))
+		key := dictionaryCacheKey(word, lang)
+		for _, entry := range wordCache.Entries() {
+			if entry.Key == key {
+				c.JSON(http.StatusOK, entry)
+				return
+			}
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "快取中沒有此單字"})
+		return
+	}
+
+	entries := wordCache.Entries()
+	summaries := make([]gin.H, 0, len(entries))
+	negative := 0
+	for _, entry := range entries {
+		if entry.NotFound {
+			negative++
+		}
+		summaries = append(summaries, gin.H{
+			"key":        entry.Key,
+			"notFound":   entry.NotFound,
+			"fetchedAt":  entry.FetchedAt,
+			"expiresAt":  entry.ExpiresAt,
+			"lastAccess": entry.LastAccess,
+		})
+	}
+
+	wordCache.mu.Lock()
+	stats := gin.H{
+		"entries":     len(entries),
+		"notFound":    negative,
+		"maxEntries":  wordCache.maxEntries,
+		"ttl":         wordCache.ttl.String(),
+		"negativeTTL": wordCache.negativeTTL.String(),
+		"hits":        wordCache.hits,
+		"misses":      wordCache.misses,
+	}
+	wordCache.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats, "entries": summaries})
+}
+
+// DeleteDictionaryCacheEntry 移除單一單字的快取（?lang= 指定語言，預設 en）
+func DeleteDictionaryCacheEntry(c *gin.Context) {
+	word := c.Param("word")
+	lang, _ := normalizeLang(c.Query("lang"))
+	if !wordCache.Delete(dictionaryCacheKey(word, lang)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "快取中沒有此單字"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("已移除 %s 的快取", word)})
+}
+
+// PurgeDictionaryCache 清除字典快取，帶 ?expired=true 時只清除過期資料
+func PurgeDictionaryCache(c *gin.Context) {
+	expiredOnly, _ := strconv.ParseBool(c.Query("expired"))
+	removed := wordCache.Purge(expiredOnly)
+	c.JSON(http.StatusOK, gin.H{"message": "字典快取已清除", "removed": removed})
+}
+
+// envDuration 讀取時間長度型的環境變數（例如 720h、10m），無效時回傳預設值
+func envDuration(name string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// envInt 讀取整數型的環境變數，無效時回傳預設值
+func envInt(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
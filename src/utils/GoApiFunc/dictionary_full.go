@@ -0,0 +1,155 @@
+package GoApiFunc
+
+import (
+	"path"
+	"strings"
+)
+
+// FullWordData 為 ?detail=full 的回傳格式，保留所有同形異義詞條
+type FullWordData struct {
+	Word    string      `json:"word"`
+	Entries []FullEntry `json:"entries"`
+	Partial bool        `json:"partial,omitempty"` // 片語查無資料，Entries 為逐字查詢的結果
+	Missing []string    `json:"missing,omitempty"`
+}
+
+// FullEntry 為單一詞條（dictionaryapi.dev 回應陣列中的一個元素）
+type FullEntry struct {
+	Word           string          `json:"word"`
+	Phonetic       string          `json:"phonetic,omitempty"`
+	Pronunciations []Pronunciation `json:"pronunciations"`
+	Meanings       []FullMeaning   `json:"meanings"`
+	SourceURLs     []string        `json:"sourceUrls,omitempty"`
+}
+
+// Pronunciation 為一種發音，Region 由音檔檔名推斷（例如 hello-us.mp3 → US）
+type Pronunciation struct {
+	Text   string `json:"text,omitempty"`
+	Audio  string `json:"audio,omitempty"`
+	Region string `json:"region,omitempty"`
+}
+
+// FullMeaning 為一個詞性下的所有義項，同反義詞已合併詞性層級與義項層級並去除重複
+type FullMeaning struct {
+	PartOfSpeech string           `json:"partOfSpeech"`
+	Definitions  []FullDefinition `json:"definitions"`
+	Synonyms     []string         `json:"synonyms"`
+	Antonyms     []string         `json:"antonyms"`
+}
+
+// FullDefinition 為單一義項
+type FullDefinition struct {
+	Definition string   `json:"definition"`
+	Example    string   `json:"example,omitempty"`
+	Synonyms   []string `json:"synonyms,omitempty"`
+	Antonyms   []string `json:"antonyms,omitempty"`
+}
+
+// BuildFullWordData 將原始 WordData 轉為完整的標準化格式
+func BuildFullWordData(word string, data []WordData) FullWordData {
+	full := FullWordData{Word: word, Entries: make([]FullEntry, 0, len(data))}
+	if len(data) > 0 {
+		full.Word = data[0].Word
+	}
+
+	for _, entry := range data {
+		fullEntry := FullEntry{
+			Word:           entry.Word,
+			Phonetic:       entry.Phonetic,
+			Pronunciations: []Pronunciation{},
+			Meanings:       make([]FullMeaning, 0, len(entry.Meanings)),
+			SourceURLs:     entry.SourceURLs,
+		}
+
+		// ✅ 保留所有有內容的發音，並標示地區
+		for _, p := range entry.Phonetics {
+			if p.Text == "" && p.Audio == "" {
+				continue
+			}
+			fullEntry.Pronunciations = append(fullEntry.Pronunciations, Pronunciation{
+				Text:   p.Text,
+				Audio:  p.Audio,
+				Region: audioRegion(p.Audio),
+			})
+		}
+		if fullEntry.Phonetic == "" && len(fullEntry.Pronunciations) > 0 {
+			for _, p := range fullEntry.Pronunciations {
+				if p.Text != "" {
+					fullEntry.Phonetic = p.Text
+					break
+				}
+			}
+		}
+
+		for _, m := range entry.Meanings {
+			meaning := FullMeaning{
+				PartOfSpeech: m.PartOfSpeech,
+				Definitions:  make([]FullDefinition, 0, len(m.Definitions)),
+			}
+			synonyms := append([]string{}, m.Synonyms...)
+			antonyms := append([]string{}, m.Antonyms...)
+
+			for _, def := range m.Definitions {
+				meaning.Definitions = append(meaning.Definitions, FullDefinition{
+					Definition: def.Definition,
+					Example:    def.Example,
+					Synonyms:   def.Synonyms,
+					Antonyms:   def.Antonyms,
+				})
+				synonyms = append(synonyms, def.Synonyms...)
+				antonyms = append(antonyms, def.Antonyms...)
+			}
+
+			meaning.Synonyms = uniqueStrings(synonyms)
+			meaning.Antonyms = uniqueStrings(antonyms)
+			fullEntry.Meanings = append(fullEntry.Meanings, meaning)
+		}
+
+		full.Entries = append(full.Entries, fullEntry)
+	}
+
+	return full
+}
+
+// buildFullResult 產生 ?detail=full 的回傳資料；片語改由逐字查詢時保留片語本身並標示 partial
+func buildFullResult(word string, raw []WordData, data FilteredWordData) FullWordData {
+	full := BuildFullWordData(word, raw)
+	if data.Partial {
+		full.Word = data.Word
+		full.Partial = true
+		full.Missing = data.Missing
+	}
+	return full
+}
+
+// audioRegion 從音檔檔名的字尾推斷發音地區，例如 hello-uk.mp3 → UK；無法判斷時回傳空字串
+func audioRegion(audio string) string {
+	if audio == "" {
+		return ""
+	}
+	name := strings.TrimSuffix(path.Base(audio), path.Ext(audio))
+	idx := strings.LastIndex(name, "-")
+	if idx < 0 {
+		return ""
+	}
+	region := name[idx+1:]
+	if len(region) != 2 {
+		return ""
+	}
+	return strings.ToUpper(region)
+}
+
+// uniqueStrings 去除重複與空白字串，保留原本順序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
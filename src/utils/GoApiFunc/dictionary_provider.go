@@ -0,0 +1,378 @@
+package GoApiFunc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 字典來源設定：
+// LEXIQUEST_DICT_PROVIDERS：以逗號指定查詢順序，例如 "offline,http"；
+// 未設定時先查 dictionaryapi.dev，離線字典目錄存在時再以它作為備援
+// LEXIQUEST_OFFLINE_DICT：離線字典檔路徑（英文）；其他語言使用同目錄下的 offline.<語言>.json
+// LEXIQUEST_DICT_HTTP_LANGS：dictionaryapi.dev 支援的語言，預設只有 en
+// LEXIQUEST_DICT_CONCURRENCY：同時對 dictionaryapi.dev 發出的請求上限
+// LEXIQUEST_DICT_RETRIES：遇到 429 / 5xx 時的最多重試次數
+const (
+	dictionaryAPIURL         = "https://api.dictionaryapi.dev/api/v2/entries/"
+	defaultOfflineDictionary = "./data/dictionary/offline.json"
+	defaultDictionaryLang    = "en"
+	providerNameHTTP         = "http"
+	providerNameOffline      = "offline"
+	dictionaryRequestTimeout = 5 * time.Second
+
+	defaultDictionaryConcurrency = 4
+	defaultDictionaryRetries     = 3
+	dictionaryRetryBase          = 500 * time.Millisecond
+	dictionaryMaxRetryWait       = 10 * time.Second
+)
+
+// DictionaryProvider 定義字典來源：依單字與語言回傳與 dictionaryapi.dev 相同格式的 WordData
+// 查無此字時應回傳 ErrWordNotFound，不支援該語言時回傳 ErrUnsupportedLanguage，
+// 讓快取與備援來源能正確處理
+type DictionaryProvider interface {
+	Name() string
+	Supports(lang string) bool
+	Lookup(word, lang string) ([]WordData, error)
+}
+
+// 字典查詢的錯誤類型：
+// ErrUnsupportedLanguage：字典來源不支援指定的語言
+// ErrNetworkUnreachable：無法連線到字典 API
+var (
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+	ErrNetworkUnreachable  = errors.New("network unreachable")
+)
+
+// UpstreamError 表示字典 API 回傳了非預期的 HTTP 狀態碼
+type UpstreamError struct {
+	Provider   string
+	StatusCode int
+	RetryAfter time.Duration // 上游透過 Retry-After 要求的等待時間
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("failed to fetch word data from %s: status %d", e.Provider, e.StatusCode)
+}
+
+// Retryable 429 與 5xx 視為暫時性錯誤，可以重試
+func (e *UpstreamError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// unsupportedLanguage 產生包含語言代碼的 ErrUnsupportedLanguage
+func unsupportedLanguage(provider, lang string) error {
+	return fmt.Errorf("%w: %q is not supported by dictionary provider %s", ErrUnsupportedLanguage, lang, provider)
+}
+
+// ✅ 目前使用的字典來源，啟動時依環境變數建立
+var dictionaryProvider DictionaryProvider = newDefaultDictionaryProvider()
+
+// HTTPDictionaryProvider 透過 dictionaryapi.dev 查詢單字
+type HTTPDictionaryProvider struct {
+	BaseURL    string
+	Languages  []string
+	Client     *http.Client
+	MaxRetries int
+
+	slots chan struct{} // 限制同時進行的請求數
+}
+
+// NewHTTPDictionaryProvider 建立使用 dictionaryapi.dev 的字典來源
+func NewHTTPDictionaryProvider() *HTTPDictionaryProvider {
+	languages := splitFilterValues(os.Getenv("LEXIQUEST_DICT_HTTP_LANGS"))
+	if len(languages) == 0 {
+		languages = []string{defaultDictionaryLang}
+	}
+	return &HTTPDictionaryProvider{
+		BaseURL:    dictionaryAPIURL,
+		Languages:  languages,
+		Client:     &http.Client{Timeout: dictionaryRequestTimeout},
+		MaxRetries: envInt("LEXIQUEST_DICT_RETRIES", defaultDictionaryRetries),
+		slots:      make(chan struct{}, envInt("LEXIQUEST_DICT_CONCURRENCY", defaultDictionaryConcurrency)),
+	}
+}
+
+func (p *HTTPDictionaryProvider) Name() string { return providerNameHTTP }
+
+func (p *HTTPDictionaryProvider) Supports(lang string) bool {
+	return matchesAny([]string{lang}, p.Languages)
+}
+
+// ✅ Lookup 直接檢查網路錯誤，移除 `isInternetAvailable`
+// 遇到 429 或 5xx 時以指數退避重試（優先採用 Retry-After），並限制同時對上游發出的請求數
+func (p *HTTPDictionaryProvider) Lookup(word, lang string) ([]WordData, error) {
+	if !p.Supports(lang) {
+		return nil, unsupportedLanguage(p.Name(), lang)
+	}
+
+	// ✅ 片語與特殊字元需跳脫，避免產生錯誤的網址
+	endpoint := p.BaseURL + lang + "/" + url.PathEscape(word)
+	for attempt := 0; ; attempt++ {
+		wordData, err := p.fetch(endpoint)
+		var upstream *UpstreamError
+		if err == nil || !errors.As(err, &upstream) || !upstream.Retryable() || attempt >= p.MaxRetries {
+			return wordData, err
+		}
+
+		wait := upstream.RetryAfter
+		if wait <= 0 {
+			wait = dictionaryRetryBase << attempt
+		}
+		if wait > dictionaryMaxRetryWait {
+			wait = dictionaryMaxRetryWait
+		}
+		fmt.Printf("🔁 字典 API 回應 %d，%v 後重試: %s\n", upstream.StatusCode, wait, word)
+		time.Sleep(wait)
+	}
+}
+
+// fetch 發出單次請求；等待並佔用一個並行名額，避免大量預取時壓垮上游
+func (p *HTTPDictionaryProvider) fetch(endpoint string) ([]WordData, error) {
+	if p.slots != nil {
+		p.slots <- struct{}{}
+		defer func() { <-p.slots }()
+	}
+
+	resp, err := p.Client.Get(endpoint)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return nil, fmt.Errorf("%w: %v", ErrNetworkUnreachable, err) // 直接判斷網路錯誤
+		}
+		return nil, fmt.Errorf("failed to fetch word data: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrWordNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &UpstreamError{
+			Provider:   p.Name(),
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	var wordData []WordData
+	if err = json.Unmarshal(body, &wordData); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON response: %v", err)
+	}
+
+	return wordData, nil
+}
+
+// parseRetryAfter 解析 Retry-After 標頭（秒數或 HTTP 日期），無法解析時回傳 0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// OfflineDictionaryProvider 從本機的字典檔查詢單字，適合沒有網路的環境
+// 英文使用 Path 指定的檔案，其他語言使用同目錄下的 offline.<語言>.json（例如 offline.fr.json）
+// 字典檔為 JSON，可以是 dictionaryapi.dev 格式的陣列，
+// 或是以單字為 key 的物件（例如由 Wiktionary / WordNet 轉出的資料）：
+//
+//	[{"word": "apple", "phonetic": "...", "meanings": [...]}, ...]
+//	{"apple": [{"word": "apple", "meanings": [...]}], ...}
+type OfflineDictionaryProvider struct {
+	Path string
+
+	mu      sync.Mutex
+	indexes map[string]*offlineIndex // 語言 → 已載入的字典
+}
+
+// offlineIndex 為單一語言的離線字典，key 一律轉為小寫
+type offlineIndex struct {
+	entries map[string][]WordData
+	err     error
+}
+
+// NewOfflineDictionaryProvider 建立讀取本機字典檔的字典來源，檔案會在第一次查詢時載入
+func NewOfflineDictionaryProvider(path string) *OfflineDictionaryProvider {
+	return &OfflineDictionaryProvider{Path: path, indexes: make(map[string]*offlineIndex)}
+}
+
+func (p *OfflineDictionaryProvider) Name() string { return providerNameOffline }
+
+// Supports 只要該語言的字典檔存在即視為支援
+func (p *OfflineDictionaryProvider) Supports(lang string) bool {
+	_, err := os.Stat(p.pathFor(lang))
+	return err == nil
+}
+
+func (p *OfflineDictionaryProvider) Lookup(word, lang string) ([]WordData, error) {
+	if !p.Supports(lang) {
+		return nil, unsupportedLanguage(p.Name(), lang)
+	}
+
+	index := p.index(lang)
+	if index.err != nil {
+		return nil, index.err
+	}
+
+	if data, found := index.entries[strings.ToLower(strings.TrimSpace(word))]; found {
+		return data, nil
+	}
+	return nil, ErrWordNotFound
+}
+
+// pathFor 回傳指定語言的字典檔路徑
+func (p *OfflineDictionaryProvider) pathFor(lang string) string {
+	if lang == defaultDictionaryLang {
+		return p.Path
+	}
+	ext := filepath.Ext(p.Path)
+	return strings.TrimSuffix(p.Path, ext) + "." + lang + ext
+}
+
+// index 取得（必要時載入）指定語言的字典
+func (p *OfflineDictionaryProvider) index(lang string) *offlineIndex {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if index, loaded := p.indexes[lang]; loaded {
+		return index
+	}
+	index := loadOfflineIndex(p.pathFor(lang))
+	p.indexes[lang] = index
+	return index
+}
+
+// loadOfflineIndex 讀取並索引離線字典檔
+func loadOfflineIndex(path string) *offlineIndex {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return &offlineIndex{err: fmt.Errorf("failed to read offline dictionary: %v", err)}
+	}
+
+	index := &offlineIndex{entries: make(map[string][]WordData)}
+
+	var byWord map[string][]WordData
+	if err := json.Unmarshal(body, &byWord); err == nil {
+		for word, data := range byWord {
+			key := strings.ToLower(word)
+			index.entries[key] = append(index.entries[key], data...)
+		}
+		return index
+	}
+
+	var list []WordData
+	if err := json.Unmarshal(body, &list); err != nil {
+		return &offlineIndex{err: fmt.Errorf("failed to parse offline dictionary: %v", err)}
+	}
+	for _, data := range list {
+		key := strings.ToLower(data.Word)
+		index.entries[key] = append(index.entries[key], data)
+	}
+	return index
+}
+
+// ChainDictionaryProvider 依序嘗試多個字典來源，回傳第一個成功的結果
+type ChainDictionaryProvider struct {
+	Providers []DictionaryProvider
+}
+
+func (p *ChainDictionaryProvider) Name() string {
+	names := make([]string, 0, len(p.Providers))
+	for _, provider := range p.Providers {
+		names = append(names, provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func (p *ChainDictionaryProvider) Supports(lang string) bool {
+	for _, provider := range p.Providers {
+		if provider.Supports(lang) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup 略過不支援該語言的來源；所有來源都查無此字時回傳 ErrWordNotFound，
+// 否則回傳第一個非「查無此字」的錯誤（例如網路錯誤），讓呼叫端知道可能只是暫時無法查詢
+func (p *ChainDictionaryProvider) Lookup(word, lang string) ([]WordData, error) {
+	if !p.Supports(lang) {
+		return nil, unsupportedLanguage(p.Name(), lang)
+	}
+
+	var firstErr error
+	for _, provider := range p.Providers {
+		if !provider.Supports(lang) {
+			continue
+		}
+		data, err := provider.Lookup(word, lang)
+		if err == nil {
+			return data, nil
+		}
+		if firstErr == nil && !errors.Is(err, ErrWordNotFound) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, ErrWordNotFound
+}
+
+// newDefaultDictionaryProvider 依環境變數組合字典來源
+func newDefaultDictionaryProvider() DictionaryProvider {
+	offlinePath := os.Getenv("LEXIQUEST_OFFLINE_DICT")
+	if offlinePath == "" {
+		offlinePath = defaultOfflineDictionary
+	}
+
+	names := splitFilterValues(os.Getenv("LEXIQUEST_DICT_PROVIDERS"))
+	if len(names) == 0 {
+		names = []string{providerNameHTTP}
+		if _, err := os.Stat(filepath.Dir(offlinePath)); err == nil {
+			names = append(names, providerNameOffline)
+		}
+	}
+
+	chain := &ChainDictionaryProvider{}
+	for _, name := range names {
+		switch name {
+		case providerNameHTTP:
+			chain.Providers = append(chain.Providers, NewHTTPDictionaryProvider())
+		case providerNameOffline:
+			chain.Providers = append(chain.Providers, NewOfflineDictionaryProvider(offlinePath))
+		default:
+			fmt.Println("⚠️ 未知的字典來源:", name)
+		}
+	}
+
+	if len(chain.Providers) == 0 {
+		return NewHTTPDictionaryProvider()
+	}
+	if len(chain.Providers) == 1 {
+		return chain.Providers[0]
+	}
+	return chain
+}
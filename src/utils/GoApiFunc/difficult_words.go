@@ -0,0 +1,159 @@
+package GoApiFunc
+
+import (
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 難字排行的排序方式與預設值
+const (
+	difficultSortErrorRate = "errorRate" // 答錯比例
+	difficultSortLapses    = "lapses"    // 答錯次數
+	difficultSortSlow      = "slow"      // 進步速度（平均每答對一次需要的作答次數）
+	difficultDefaultLimit  = 20
+)
+
+// DifficultWord 為難字排行中的一個單字；同一個單字的拼字與聽寫統計分別列出
+type DifficultWord struct {
+	Category    string  `json:"category"`
+	Filename    string  `json:"filename"`
+	Word        string  `json:"word"`
+	Mode        string  `json:"mode"` // spelling 或 dictation
+	Translation string  `json:"translation"`
+	Type        string  `json:"type"`
+	Reviews     int     `json:"reviews"`
+	Correct     int     `json:"correct"`
+	Lapses      int     `json:"lapses"`
+	ErrorRate   float64 `json:"errorRate"` // 答錯比例（0～1）
+	Weight      float64 `json:"weight"`
+	Slowness    float64 `json:"slowness"` // 作答次數 ÷ 答對次數（沒有答對時以 1 計算），越大代表進步越慢
+}
+
+// wordSlowness 依作答統計計算平均每答對一次需要的作答次數
+func wordSlowness(stat *WordStat) float64 {
+	return math.Round(float64(stat.Reviews)/math.Max(1, float64(stat.Correct))*100) / 100
+}
+
+// GetDifficultWords 依作答統計列出最難的單字
+// ?sort=errorRate（預設）/ lapses / slow 指定排序方式；?category=、?filename= 限定範圍；
+// ?mode=spelling|dictation 只看其中一種模式（預設兩者都列出）；
+// ?minReviews= 排除練習次數太少的單字（預設 1）；?limit= 限制回傳數量（預設 20）
+func GetDifficultWords(c *gin.Context) {
+	sortBy := c.DefaultQuery("sort", difficultSortErrorRate)
+	if sortBy != difficultSortErrorRate && sortBy != difficultSortLapses && sortBy != difficultSortSlow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort 只能是 errorRate、lapses 或 slow"})
+		return
+	}
+	mode := c.Query("mode")
+	if mode != "" && mode != forecastModeSpelling && mode != quizModeDictation {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode 只能是 spelling 或 dictation"})
+		return
+	}
+	minReviews := queryPositiveInt(c, "minReviews", 1)
+	limit := queryPositiveInt(c, "limit", difficultDefaultLimit)
+
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	words := collectDifficultWords(userData, mode, c.Query("category"), c.Query("filename"), minReviews)
+
+	// ✅ 依指定的指標由難到易排序，相同時以練習次數多者優先，最後依單字與模式排序讓結果穩定
+	metric := func(w DifficultWord) float64 {
+		switch sortBy {
+		case difficultSortLapses:
+			return float64(w.Lapses)
+		case difficultSortSlow:
+			return w.Slowness
+		default:
+			return w.ErrorRate
+		}
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if mi, mj := metric(words[i]), metric(words[j]); mi != mj {
+			return mi > mj
+		}
+		if words[i].Reviews != words[j].Reviews {
+			return words[i].Reviews > words[j].Reviews
+		}
+		if words[i].Word != words[j].Word {
+			return words[i].Word < words[j].Word
+		}
+		return words[i].Mode < words[j].Mode
+	})
+
+	total := len(words)
+	if len(words) > limit {
+		words = words[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sort": sortBy, "total": total, "words": words})
+}
+
+// collectDifficultWords 列出拼字與聽寫作答統計中的單字（mode 為空時兩者都列出），
+// 以題庫內容補上翻譯與詞性；已從題庫刪除或練習次數少於 minReviews 的單字不列入
+func collectDifficultWords(userData *UserData, mode, categoryFilter, filenameFilter string, minReviews int) []DifficultWord {
+	sources := []struct {
+		mode      string
+		wordStats map[string]map[string]map[string]*WordStat
+		progress  map[string]map[string]map[string]float64
+	}{
+		{forecastModeSpelling, userData.WordStats, userData.Progress},
+		{quizModeDictation, userData.DictationStats, userData.DictationProgress},
+	}
+
+	words := []DifficultWord{}
+	indexes := make(map[string]map[string]map[string]string) // 題庫路徑 → 單字 → 題庫條目
+	for _, source := range sources {
+		if mode != "" && mode != source.mode {
+			continue
+		}
+		for category, files := range source.wordStats {
+			if categoryFilter != "" && category != categoryFilter {
+				continue
+			}
+			for filename, stats := range files {
+				if filenameFilter != "" && filename != filenameFilter {
+					continue
+				}
+
+				listKey := filepath.Join(category, filename)
+				if _, loaded := indexes[listKey]; !loaded {
+					indexes[listKey] = loadWordlistIndex(category, filename)
+				}
+				for word, stat := range stats {
+					entry, exists := indexes[listKey][word]
+					if !exists || stat.Reviews < minReviews {
+						continue
+					}
+
+					weight, practiced := source.progress[category][filename][word]
+					if !practiced {
+						weight = defaultWeight
+					}
+					words = append(words, DifficultWord{
+						Category:    category,
+						Filename:    filename,
+						Word:        word,
+						Mode:        source.mode,
+						Translation: entry["translation"],
+						Type:        entry["type"],
+						Reviews:     stat.Reviews,
+						Correct:     stat.Correct,
+						Lapses:      stat.Lapses,
+						ErrorRate:   math.Round(float64(stat.Reviews-stat.Correct)/float64(stat.Reviews)*1000) / 1000,
+						Weight:      weight,
+						Slowness:    wordSlowness(stat),
+					})
+				}
+			}
+		}
+	}
+	return words
+}
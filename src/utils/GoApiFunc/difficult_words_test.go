@@ -0,0 +1,67 @@
+package GoApiFunc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useTempDataDir 切換到暫存目錄，讓 ./data 底下的題庫與用戶檔案不影響專案資料
+func useTempDataDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// writeWordlist 在 ./data/wordlists 建立題庫檔案，每行格式為「單字,翻譯,詞性」
+func writeWordlist(t *testing.T, category, filename string, lines ...string) {
+	t.Helper()
+	dir := filepath.Join(wordlistPath, category)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, filename+".txt"), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDifficultWordsSlowness(t *testing.T) {
+	useTempDataDir(t)
+	writeWordlist(t, "difficult", "list", "apple,蘋果,noun", "river,河流,noun", "orbit,軌道,noun")
+
+	userData := &UserData{
+		WordStats: map[string]map[string]map[string]*WordStat{"difficult": {"list": {
+			"apple": {Reviews: 10, Correct: 9, Lapses: 1},
+			"river": {Reviews: 10, Correct: 4, Lapses: 6},
+		}}},
+		DictationStats: map[string]map[string]map[string]*WordStat{"difficult": {"list": {
+			"orbit": {Reviews: 5, Correct: 0, Lapses: 5},
+		}}},
+	}
+
+	words := collectDifficultWords(userData, "", "", "", 1)
+	byWord := make(map[string]DifficultWord)
+	for _, word := range words {
+		byWord[word.Word] = word
+	}
+	if len(byWord) != 3 {
+		t.Fatalf("words = %+v, want apple, river and orbit", words)
+	}
+	if apple, river := byWord["apple"].Slowness, byWord["river"].Slowness; apple != 1.11 || river != 2.5 {
+		t.Errorf("slowness apple = %v, river = %v, want 1.11 and 2.5", apple, river)
+	}
+	if orbit := byWord["orbit"]; orbit.Mode != quizModeDictation || orbit.Slowness != 5 || orbit.Translation != "軌道" {
+		t.Errorf("dictation-only word = %+v", orbit)
+	}
+
+	if got := collectDifficultWords(userData, forecastModeSpelling, "", "", 1); len(got) != 2 {
+		t.Errorf("spelling words = %+v, want 2", got)
+	}
+}
@@ -0,0 +1,408 @@
+package GoApiFunc
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// userSchemaVersion 為 user.json 的資料版本：
+// 1（或未標示）：進度、聽寫進度與作答統計以單字文字為 key
+// 2：改以單字 ID 為 key（題庫中的 id= 欄位，沒有時為單字內容的雜湊），修正拼字或改名後進度不會遺失
+const userSchemaVersion = 2
+
+// EntryRecord 記錄單字 ID 最後一次對應的拼字與曾經使用過的拼字
+type EntryRecord struct {
+	Word    string   `json:"word"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// wordHashID 以單字內容（不分大小寫、合併多餘空白）產生穩定的 ID
+func wordHashID(word string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(word)), " ")
+	sum := sha1.Sum([]byte(normalized))
+	return "w" + hex.EncodeToString(sum[:])[:12]
+}
+
+// entryID 回傳題庫單字的 ID：優先使用題庫中的 id= 欄位，否則使用單字內容的雜湊
+func entryID(entry map[string]string) string {
+	if id := strings.TrimSpace(entry["id"]); id != "" {
+		return id
+	}
+	return wordHashID(entry["word"])
+}
+
+// wordlistIDs 為單一題庫中單字與 ID 的雙向對照
+type wordlistIDs struct {
+	byWord map[string]string
+	byID   map[string]string
+}
+
+// cachedWordlistIDs 為已解析的題庫對照，題庫檔案的修改時間或大小改變時重新解析
+type cachedWordlistIDs struct {
+	modTime time.Time
+	size    int64
+	ids     *wordlistIDs
+}
+
+// ✅ 題庫的單字與 ID 對照在各請求間共用，每次讀寫 user.json 不必重新解析題庫
+var (
+	wordlistIDCache   = make(map[string]*cachedWordlistIDs)
+	wordlistIDCacheMu sync.Mutex
+)
+
+// loadWordlistIDs 取得題庫的單字與 ID 對照（唯讀）；題庫不存在時回傳空的對照
+func loadWordlistIDs(category, filename string) *wordlistIDs {
+	path := filepath.Join(wordlistPath, category, filename+".txt")
+	info, err := os.Stat(path)
+	if err != nil {
+		return &wordlistIDs{byWord: map[string]string{}, byID: map[string]string{}}
+	}
+
+	wordlistIDCacheMu.Lock()
+	cached, exists := wordlistIDCache[path]
+	wordlistIDCacheMu.Unlock()
+	if exists && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.ids
+	}
+
+	ids := &wordlistIDs{byWord: make(map[string]string), byID: make(map[string]string)}
+	if words, err := parseWordlistFile(path); err == nil {
+		for _, word := range words {
+			ids.byWord[word["word"]] = word["id"]
+			ids.byID[word["id"]] = word["word"]
+		}
+	}
+
+	wordlistIDCacheMu.Lock()
+	wordlistIDCache[path] = &cachedWordlistIDs{modTime: info.ModTime(), size: info.Size(), ids: ids}
+	wordlistIDCacheMu.Unlock()
+	return ids
+}
+
+// entryResolver 在讀寫 user.json 時轉換單字與 ID，並快取已讀取的題庫
+type entryResolver struct {
+	userData *UserData
+	lists    map[string]*wordlistIDs
+}
+
+func newEntryResolver(userData *UserData) *entryResolver {
+	return &entryResolver{userData: userData, lists: make(map[string]*wordlistIDs)}
+}
+
+// list 讀取題庫的單字與 ID 對照（見 loadWordlistIDs）；題庫不存在時回傳空的對照
+func (r *entryResolver) list(category, filename string) *wordlistIDs {
+	key := filepath.Join(category, filename)
+	if ids, exists := r.lists[key]; exists {
+		return ids
+	}
+	ids := loadWordlistIDs(category, filename)
+	r.lists[key] = ids
+	return ids
+}
+
+// wordFor 將 user.json 中的 ID 轉為目前題庫中的拼字
+// 題庫中找不到時先查詢別名（進度整理時記錄的舊 ID），再使用最後一次記錄的拼字
+func (r *entryResolver) wordFor(category, filename, id string) string {
+	ids := r.list(category, filename)
+	if word, exists := ids.byID[id]; exists {
+		return word
+	}
+	if alias, exists := r.userData.IDAliases[id]; exists {
+		if word, found := ids.byID[alias]; found {
+			return word
+		}
+	}
+	word := id
+	if record := r.userData.Entries[id]; record != nil && record.Word != "" {
+		word = record.Word
+	}
+	// 記住孤立單字原本的 ID，存檔時沿用
+	if r.userData.orphanIDs == nil {
+		r.userData.orphanIDs = make(map[string]string)
+	}
+	r.userData.orphanIDs[orphanKey(category, filename, word)] = id
+	return word
+}
+
+// idFor 將單字轉為 ID：題庫中的單字使用題庫的 ID，孤立的單字沿用讀取時的 ID，其餘使用內容雜湊
+func (r *entryResolver) idFor(category, filename, word string) string {
+	if id, exists := r.list(category, filename).byWord[word]; exists {
+		return id
+	}
+	if id, exists := r.userData.orphanIDs[orphanKey(category, filename, word)]; exists {
+		return id
+	}
+	return wordHashID(word)
+}
+
+// remember 更新 ID 對應的拼字；拼字改變時把舊拼字加入別名
+func (r *entryResolver) remember(id, word string) {
+	if r.userData.Entries == nil {
+		r.userData.Entries = make(map[string]*EntryRecord)
+	}
+	record, exists := r.userData.Entries[id]
+	if !exists {
+		r.userData.Entries[id] = &EntryRecord{Word: word}
+		return
+	}
+	if record.Word != word && record.Word != "" && !containsString(record.Aliases, record.Word) {
+		record.Aliases = append(record.Aliases, record.Word)
+	}
+	record.Word = word
+}
+
+// orphanKey 組合孤立單字的查詢 key
+func orphanKey(category, filename, word string) string {
+	return category + "\x00" + filename + "\x00" + word
+}
+
+// containsString 判斷字串是否在列表中
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// translateKeys 轉換「分類 → 題庫 → key」巢狀 map 最內層的 key；轉換後重複的 key 以 merge 合併
+func translateKeys[T any](m map[string]map[string]map[string]T, convert func(category, filename, key string) string, merge func(a, b T) T) map[string]map[string]map[string]T {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]map[string]map[string]T, len(m))
+	for category, files := range m {
+		result[category] = make(map[string]map[string]T, len(files))
+		for filename, entries := range files {
+			converted := make(map[string]T, len(entries))
+			for key, value := range entries {
+				newKey := convert(category, filename, key)
+				if existing, exists := converted[newKey]; exists {
+					value = merge(existing, value)
+				}
+				converted[newKey] = value
+			}
+			result[category][filename] = converted
+		}
+	}
+	return result
+}
+
+// 權重與作答統計在 key 重複時的合併方式：權重取較低（較熟）者，作答統計加總，單字狀態保留先讀到的
+func mergeWeights(a, b float64) float64 { return min(a, b) }
+
+func keepFirst(a, _ string) string { return a }
+
+func mergeStats(a, b *WordStat) *WordStat {
+	merged := *a
+	merged.Reviews += b.Reviews
+	merged.Correct += b.Correct
+	merged.Lapses += b.Lapses
+	if b.FirstSeen.Before(merged.FirstSeen) {
+		merged.FirstSeen = b.FirstSeen
+	}
+	if b.LastReviewed.After(merged.LastReviewed) {
+		merged.LastReviewed = b.LastReviewed
+		merged.CorrectStreak = b.CorrectStreak
+	}
+	if b.LastLapse != nil && (merged.LastLapse == nil || b.LastLapse.After(*merged.LastLapse)) {
+		merged.LastLapse = b.LastLapse
+	}
+	return &merged
+}
+
+// fromDisk 將讀入的 user.json（ID 為 key）轉為程式內部使用的單字 key
+// 開啟共用進度時，已有進度的題庫先套用共用權重；其他題庫在請求使用時才套用（見 applySharedWeights）
+func (data *UserData) fromDisk() {
+	resolver := newEntryResolver(data)
+	data.Progress = translateKeys(data.Progress, resolver.wordFor, mergeWeights)
+	data.DictationProgress = translateKeys(data.DictationProgress, resolver.wordFor, mergeWeights)
+	data.WordStats = translateKeys(data.WordStats, resolver.wordFor, mergeStats)
+	data.DictationStats = translateKeys(data.DictationStats, resolver.wordFor, mergeStats)
+	data.WordStates = translateKeys(data.WordStates, resolver.wordFor, keepFirst)
+
+	if data.SharedProgress {
+		for _, progress := range []map[string]map[string]map[string]float64{data.Progress, data.DictationProgress} {
+			for category, files := range progress {
+				for filename := range files {
+					data.applySharedWeights(category, filename)
+				}
+			}
+		}
+	}
+
+	data.loadedProgress = copyWeights(data.Progress)
+	data.loadedDictation = copyWeights(data.DictationProgress)
+}
+
+// toDisk 產生以 ID 為 key 的存檔資料（不修改 data 本身的進度 map），並更新拼字紀錄與共用權重
+func (data *UserData) toDisk() *UserData {
+	resolver := newEntryResolver(data)
+	idFor := func(category, filename, word string) string {
+		id := resolver.idFor(category, filename, word)
+		resolver.remember(id, word)
+		return id
+	}
+
+	if data.SharedProgress {
+		data.Shared = syncSharedWeights(data.Shared, data.Progress, data.loadedProgress, resolver.idFor)
+		data.SharedDictation = syncSharedWeights(data.SharedDictation, data.DictationProgress, data.loadedDictation, resolver.idFor)
+	}
+
+	disk := *data
+	disk.SchemaVersion = userSchemaVersion
+	disk.Progress = translateKeys(data.Progress, idFor, mergeWeights)
+	disk.DictationProgress = translateKeys(data.DictationProgress, idFor, mergeWeights)
+	disk.WordStats = translateKeys(data.WordStats, idFor, mergeStats)
+	disk.DictationStats = translateKeys(data.DictationStats, idFor, mergeStats)
+	disk.WordStates = translateKeys(data.WordStates, idFor, keepFirst)
+	disk.Entries = data.Entries // remember 可能在轉換時才建立 Entries
+	if disk.Progress == nil {
+		disk.Progress = make(map[string]map[string]map[string]float64)
+	}
+	return &disk
+}
+
+// applySharedWeights 開啟共用進度時，將共用權重套用到指定題庫中相同 ID 的單字，每個題庫只套用一次；
+// 讀取或練習特定題庫前呼叫，沒有開啟過的題庫不會被寫入權重，統計與練習紀錄也不受影響
+func (data *UserData) applySharedWeights(category, filename string) {
+	if !data.SharedProgress {
+		return
+	}
+	key := filepath.Join(category, filename)
+	if data.sharedApplied[key] {
+		return
+	}
+	if data.sharedApplied == nil {
+		data.sharedApplied = make(map[string]bool)
+	}
+	data.sharedApplied[key] = true
+
+	ids := loadWordlistIDs(category, filename)
+	if data.Progress == nil {
+		data.Progress = make(map[string]map[string]map[string]float64)
+	}
+	fillSharedWeights(data.Progress, data.loadedProgress, data.Shared, ids, category, filename)
+	if len(data.SharedDictation) > 0 {
+		if data.DictationProgress == nil {
+			data.DictationProgress = make(map[string]map[string]map[string]float64)
+		}
+		fillSharedWeights(data.DictationProgress, data.loadedDictation, data.SharedDictation, ids, category, filename)
+	}
+}
+
+// fillSharedWeights 將共用權重寫入題庫的權重；讀取後才套用時一併寫入 loaded，不會被視為本次請求的修改
+func fillSharedWeights(progress, loaded map[string]map[string]map[string]float64, shared map[string]float64, ids *wordlistIDs, category, filename string) {
+	for id, weight := range shared {
+		word, exists := ids.byID[id]
+		if !exists {
+			continue
+		}
+		EnsureCategoryAndFilename(progress, category, filename)
+		progress[category][filename][word] = weight
+		if loaded != nil {
+			EnsureCategoryAndFilename(loaded, category, filename)
+			loaded[category][filename][word] = weight
+		}
+	}
+}
+
+// syncSharedWeights 依本次請求中改變的權重更新共用權重：
+// 讀取後被修改或新增的單字寫入共用權重；被刪除且其他題庫也不再使用的 ID 從共用權重移除
+func syncSharedWeights(shared map[string]float64, current, loaded map[string]map[string]map[string]float64, idFor func(category, filename, word string) string) map[string]float64 {
+	if shared == nil {
+		shared = make(map[string]float64)
+	}
+
+	inUse := make(map[string]bool)
+	seeded := make(map[string]bool) // 本次才加入共用權重的 ID（例如剛開啟共用時），多個題庫取較低的權重
+	for category, files := range current {
+		for filename, weights := range files {
+			for word, weight := range weights {
+				id := idFor(category, filename, word)
+				inUse[id] = true
+				before, existed := loaded[category][filename][word]
+				value, known := shared[id]
+				switch {
+				case !known:
+					shared[id] = weight
+					seeded[id] = true
+				case seeded[id]:
+					shared[id] = min(value, weight)
+				case !existed || before != weight:
+					shared[id] = weight
+				}
+			}
+		}
+	}
+	for category, files := range loaded {
+		for filename, weights := range files {
+			for word := range weights {
+				if _, stillThere := current[category][filename][word]; stillThere {
+					continue
+				}
+				if id := idFor(category, filename, word); !inUse[id] {
+					delete(shared, id)
+				}
+			}
+		}
+	}
+	return shared
+}
+
+// copyWeights 複製權重 map，用於比對本次請求修改了哪些權重
+func copyWeights(m map[string]map[string]map[string]float64) map[string]map[string]map[string]float64 {
+	return translateKeys(m, func(_, _, key string) string { return key }, mergeWeights)
+}
+
+// recordEntryAlias 進度整理把單字合併到新拼字時，記錄舊 ID 與舊拼字
+func recordEntryAlias(userData *UserData, from, to ProgressLocation) {
+	resolver := newEntryResolver(userData)
+	fromID := resolver.idFor(from.Category, from.Filename, from.Word)
+	toID := resolver.idFor(to.Category, to.Filename, to.Word)
+	if fromID == toID {
+		return
+	}
+	if userData.IDAliases == nil {
+		userData.IDAliases = make(map[string]string)
+	}
+	userData.IDAliases[fromID] = toID
+
+	resolver.remember(toID, to.Word)
+	if record := userData.Entries[toID]; !containsString(record.Aliases, from.Word) {
+		record.Aliases = append(record.Aliases, from.Word)
+	}
+}
+
+// backupLegacyUserFile 在舊版（以單字為 key）的用戶檔案第一次被讀取時備份原檔；
+// 轉換後的資料不在讀取時寫回，而是由下一次鎖定後的存檔寫入新版，避免覆蓋其他請求剛存入的進度
+func backupLegacyUserFile(data *UserData) error {
+	backup := data.path + fmt.Sprintf(".v%d.bak", max(data.SchemaVersion, 1))
+	original, err := os.ReadFile(data.path)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if os.IsExist(err) {
+		return nil // 已經備份過，保留最早的原檔
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(original); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Println("🔄 讀取舊版用戶檔案，下次存檔時將改以單字 ID 記錄進度，原檔備份於", backup)
+	return nil
+}
@@ -0,0 +1,102 @@
+package GoApiFunc
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 排行榜設定：
+// leaderboardDays：每週排行的統計天數（含今天，依各用戶時區）
+// leaderboardMinReviews：列入正確率排行所需的最少作答數，避免只答一題就 100%
+const (
+	leaderboardDays       = 7
+	leaderboardMinReviews = 20
+)
+
+// LeaderboardEntry 為排行榜中的一位用戶；Value 依排行種類為複習數、正確率（0～1）或連續天數
+type LeaderboardEntry struct {
+	Rank     int     `json:"rank"`
+	Profile  string  `json:"profile"`
+	Username string  `json:"username"`
+	Value    float64 `json:"value"`
+	Reviewed int     `json:"reviewed"` // 統計期間的複習數
+}
+
+// weeklyActivity 加總最近 leaderboardDays 天（用戶時區）的複習數與答對數
+func weeklyActivity(userData *UserData, now time.Time) (reviewed, correct int) {
+	today, _ := time.Parse(dayLayout, userDay(userData, now))
+	for i := 0; i < leaderboardDays; i++ {
+		if activity := userData.Daily[today.AddDate(0, 0, -i).Format(dayLayout)]; activity != nil {
+			reviewed += activity.Reviewed
+			correct += activity.Correct
+		}
+	}
+	return reviewed, correct
+}
+
+// rankEntries 依 Value 由大到小排序並編上名次，數值相同時名次相同（例如 1、1、3）
+func rankEntries(entries []LeaderboardEntry) []LeaderboardEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return entries[i].Profile < entries[j].Profile
+	})
+	for i := range entries {
+		if i > 0 && entries[i].Value == entries[i-1].Value {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+	}
+	return entries
+}
+
+// GetLeaderboard 回傳區域網內公開用戶的每週複習數、每週正確率與目前連續天數排行
+// 只有在設定中開啟 leaderboard 的用戶會被列出
+func GetLeaderboard(c *gin.Context) {
+	now := time.Now()
+	words, accuracy, streak := []LeaderboardEntry{}, []LeaderboardEntry{}, []LeaderboardEntry{}
+
+	participants := 0
+	for _, name := range listProfileNames() {
+		userData, err := loadProfileData(name)
+		if err != nil || !userData.Leaderboard {
+			continue
+		}
+		participants++
+
+		username := userData.Username
+		if username == "" {
+			username = name
+		}
+		reviewed, correct := weeklyActivity(userData, now)
+		current, _ := computeStreaks(userData, now)
+
+		entry := LeaderboardEntry{Profile: name, Username: username, Reviewed: reviewed}
+		words = append(words, withValue(entry, float64(reviewed)))
+		streak = append(streak, withValue(entry, float64(current)))
+		if reviewed >= leaderboardMinReviews {
+			accuracy = append(accuracy, withValue(entry, math.Round(float64(correct)/float64(reviewed)*1000)/1000))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":         leaderboardDays,
+		"minReviews":   leaderboardMinReviews,
+		"participants": participants,
+		"words":        rankEntries(words),
+		"accuracy":     rankEntries(accuracy),
+		"streak":       rankEntries(streak),
+	})
+}
+
+// withValue 複製排行項目並設定數值
+func withValue(entry LeaderboardEntry, value float64) LeaderboardEntry {
+	entry.Value = value
+	return entry
+}
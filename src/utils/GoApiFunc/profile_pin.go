@@ -0,0 +1,275 @@
+package GoApiFunc
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 用戶 PIN 設定：
+// 建立用戶時需設定 PIN，之後以 X-LexiQuest-PIN Header（或 ?pin=）證明身分；
+// default 用戶（伺服器擁有者的 user.json）的 PIN 由環境變數 LEXIQUEST_OWNER_PIN 設定。
+// 只有通過 PIN 驗證的請求才能使用老師權限；沒有 PIN 的舊用戶仍可使用，但任何人都能以該用戶身分操作。
+// PIN 以 bcrypt 雜湊儲存；同一用戶連續輸錯 pinMaxFailures 次後暫停驗證，之後每次輸錯暫停時間加倍（最長 pinLockoutMax）
+const (
+	profilePINHeader   = "X-LexiQuest-PIN"
+	profileAuthKey     = "profileAuthenticated"
+	ownerPINEnv        = "LEXIQUEST_OWNER_PIN"
+	profilePINMinRune  = 4
+	profilePINMaxBytes = 72 // bcrypt 只接受 72 位元組以內的密碼
+
+	pinMaxFailures  = 5
+	pinLockoutBase  = 30 * time.Second
+	pinLockoutMax   = 15 * time.Minute
+	pinFailureReset = time.Hour // 超過此時間沒有再輸錯時，清除錯誤次數
+)
+
+// ProfileCredential 為用戶 PIN 的 bcrypt 雜湊（不儲存原始 PIN）
+type ProfileCredential struct {
+	Hash string `json:"hash"`
+
+	owner string // default 用戶的 PIN（來自環境變數，不需要雜湊）
+}
+
+// validProfilePIN 檢查 PIN 至少 4 個字元、最多 72 位元組且不含空白
+func validProfilePIN(pin string) bool {
+	return len([]rune(pin)) >= profilePINMinRune && len(pin) <= profilePINMaxBytes && !strings.ContainsAny(pin, " \t\r\n")
+}
+
+// newProfileCredential 以 bcrypt 建立 PIN 雜湊
+func newProfileCredential(pin string) (*ProfileCredential, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return &ProfileCredential{Hash: string(hash)}, nil
+}
+
+// ✅ 記錄最近驗證成功的 PIN（雜湊 → PIN 的 SHA-256），同一個 PIN 的後續請求不必每次重新計算 bcrypt
+var (
+	verifiedPINs   = make(map[string][sha256.Size]byte)
+	verifiedPINsMu sync.Mutex
+)
+
+// matches 比對 PIN 是否正確
+func (cred *ProfileCredential) matches(pin string) bool {
+	digest := sha256.Sum256([]byte(pin))
+	if cred.owner != "" {
+		owner := sha256.Sum256([]byte(cred.owner))
+		return subtle.ConstantTimeCompare(digest[:], owner[:]) == 1
+	}
+
+	verifiedPINsMu.Lock()
+	verified, cached := verifiedPINs[cred.Hash]
+	verifiedPINsMu.Unlock()
+	if cached && subtle.ConstantTimeCompare(digest[:], verified[:]) == 1 {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(cred.Hash), []byte(pin)) != nil {
+		return false
+	}
+	verifiedPINsMu.Lock()
+	verifiedPINs[cred.Hash] = digest
+	verifiedPINsMu.Unlock()
+	return true
+}
+
+// pinAttempts 為單一用戶的 PIN 錯誤紀錄
+type pinAttempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// pinLimiter 記錄各用戶連續輸錯 PIN 的次數，避免區域網內的其他人以大量請求猜出 PIN
+type pinLimiter struct {
+	mu       sync.Mutex
+	attempts map[string]*pinAttempts
+}
+
+var profilePINLimiter = &pinLimiter{attempts: make(map[string]*pinAttempts)}
+
+// locked 回傳用戶是否仍在暫停驗證期間，以及剩餘的時間
+func (l *pinLimiter) locked(name string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	attempts, exists := l.attempts[name]
+	if !exists || !now.Before(attempts.lockedUntil) {
+		return 0, false
+	}
+	return attempts.lockedUntil.Sub(now), true
+}
+
+// failed 記錄一次 PIN 錯誤；達到 pinMaxFailures 次後開始暫停驗證
+func (l *pinLimiter) failed(name string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	attempts, exists := l.attempts[name]
+	if !exists || now.Sub(attempts.lastFailure) > pinFailureReset {
+		attempts = &pinAttempts{}
+		l.attempts[name] = attempts
+	}
+	attempts.failures++
+	attempts.lastFailure = now
+	if attempts.failures < pinMaxFailures {
+		return
+	}
+
+	lockout := pinLockoutMax
+	if extra := attempts.failures - pinMaxFailures; extra < 16 {
+		lockout = min(pinLockoutMax, pinLockoutBase<<extra)
+	}
+	attempts.lockedUntil = now.Add(lockout)
+}
+
+// reset 清除用戶的 PIN 錯誤紀錄（驗證成功或 PIN 被重設時）
+func (l *pinLimiter) reset(name string) {
+	l.mu.Lock()
+	delete(l.attempts, name)
+	l.mu.Unlock()
+}
+
+// verifyProfilePIN 驗證請求提供的 PIN，成功時回傳 200，否則回傳錯誤狀態與訊息
+// 未提供 PIN 的請求不計入錯誤次數；暫停驗證期間即使 PIN 正確也會拒絕
+func verifyProfilePIN(c *gin.Context, name string, credential *ProfileCredential, now time.Time) (int, string) {
+	pin := requestPIN(c)
+	if pin == "" {
+		return http.StatusUnauthorized, "請提供正確的用戶 PIN"
+	}
+	if wait, locked := profilePINLimiter.locked(name, now); locked {
+		seconds := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		return http.StatusTooManyRequests, fmt.Sprintf("PIN 錯誤次數過多，請於 %d 秒後再試", seconds)
+	}
+	if !credential.matches(pin) {
+		profilePINLimiter.failed(name, now)
+		return http.StatusUnauthorized, "請提供正確的用戶 PIN"
+	}
+	profilePINLimiter.reset(name)
+	return http.StatusOK, ""
+}
+
+// profileCredential 讀取用戶的 PIN；沒有設定 PIN 時回傳 nil
+// default 用戶使用 LEXIQUEST_OWNER_PIN，其他用戶只讀取檔案中的 credential 欄位，不必轉換整份進度
+func profileCredential(name string) (*ProfileCredential, error) {
+	if name == defaultProfile {
+		pin := os.Getenv(ownerPINEnv)
+		if pin == "" {
+			return nil, nil
+		}
+		return &ProfileCredential{owner: pin}, nil
+	}
+
+	body, err := os.ReadFile(profileFilePath(name))
+	if err != nil {
+		return nil, err
+	}
+	var stored struct {
+		Credential *ProfileCredential `json:"credential"`
+	}
+	if err := json.Unmarshal(body, &stored); err != nil {
+		return nil, err
+	}
+	return stored.Credential, nil
+}
+
+// requestPIN 回傳請求提供的 PIN
+func requestPIN(c *gin.Context) string {
+	if pin := c.GetHeader(profilePINHeader); pin != "" {
+		return pin
+	}
+	return c.Query("pin")
+}
+
+// requestAuthenticated 判斷請求是否通過用戶 PIN 驗證（見 ProfileMiddleware）
+func requestAuthenticated(c *gin.Context) bool {
+	return c.GetBool(profileAuthKey)
+}
+
+// UpdatePINRequest 定義設定 PIN 的請求資料
+type UpdatePINRequest struct {
+	PIN string `json:"pin"`
+}
+
+// UpdateMyPIN 設定或變更自己的 PIN（PUT /api/user/pin）；已設定 PIN 時需先以原本的 PIN 通過驗證
+func UpdateMyPIN(c *gin.Context) {
+	var req UpdatePINRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !validProfilePIN(req.PIN) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "PIN 需至少 4 個字元、最多 72 位元組且不含空白"})
+		return
+	}
+	name := requestProfile(c)
+	if name == defaultProfile {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "default 用戶的 PIN 請以環境變數 " + ownerPINEnv + " 設定"})
+		return
+	}
+	if err := setProfilePIN(name, req.PIN); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法設定 PIN"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "PIN 已更新", "profile": name})
+}
+
+// ResetProfilePIN 由老師重設學生的 PIN（PUT /api/profiles/:profile/pin），不能重設其他老師的 PIN
+func ResetProfilePIN(c *gin.Context) {
+	var req UpdatePINRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !validProfilePIN(req.PIN) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "PIN 需至少 4 個字元、最多 72 位元組且不含空白"})
+		return
+	}
+	if !requireTeacher(c) {
+		return
+	}
+
+	name := c.Param("profile")
+	if !validProfileName(name) || name == defaultProfile || !profileExists(name) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到指定的用戶"})
+		return
+	}
+	userData, err := loadProfileData(name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+	if profileRole(name, userData) == roleTeacher {
+		c.JSON(http.StatusForbidden, gin.H{"error": "無法重設老師的 PIN"})
+		return
+	}
+	if err := setProfilePIN(name, req.PIN); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法設定 PIN"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "PIN 已重設", "profile": name})
+}
+
+// setProfilePIN 寫入用戶的 PIN 雜湊，並清除該用戶的 PIN 錯誤紀錄
+func setProfilePIN(name, pin string) error {
+	unlock := lockProfile(name)
+	defer unlock()
+	userData, err := loadProfileData(name)
+	if err != nil {
+		return err
+	}
+	credential, err := newProfileCredential(pin)
+	if err != nil {
+		return err
+	}
+	userData.Credential = credential
+	if err := SaveUserData(userData); err != nil {
+		return err
+	}
+	profilePINLimiter.reset(name)
+	return nil
+}
@@ -0,0 +1,153 @@
+package GoApiFunc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestProfile 在暫存目錄建立設定了 PIN 的用戶
+func newTestProfile(t *testing.T, name, pin string) {
+	t.Helper()
+	credential, err := newProfileCredential(pin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userData := &UserData{
+		Username:      name,
+		Progress:      make(map[string]map[string]map[string]float64),
+		SchemaVersion: userSchemaVersion,
+		Credential:    credential,
+		path:          profileFilePath(name),
+	}
+	if err := SaveUserData(userData); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { profilePINLimiter.reset(name) })
+}
+
+// newProfileRouter 註冊回傳驗證結果的路由
+func newProfileRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/whoami", ProfileMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"profile": requestProfile(c), "authenticated": requestAuthenticated(c)})
+	})
+	return r
+}
+
+// getAs 以指定的用戶與 PIN 發出請求
+func getAs(r http.Handler, profile, pin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Header.Set(profileHeader, profile)
+	if pin != "" {
+		req.Header.Set(profilePINHeader, pin)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestResolveProfilePIN(t *testing.T) {
+	useTempDataDir(t)
+	newTestProfile(t, "pin-amy", "2468")
+	r := newProfileRouter()
+
+	if w := getAs(r, "pin-amy", "2468"); w.Code != http.StatusOK || w.Body.String() != `{"authenticated":true,"profile":"pin-amy"}` {
+		t.Fatalf("correct PIN: %d %s", w.Code, w.Body.String())
+	}
+	if w := getAs(r, "pin-nobody", "2468"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown profile: %d", w.Code)
+	}
+
+	// 未提供 PIN 不計入錯誤次數
+	for i := 0; i < pinMaxFailures+2; i++ {
+		if w := getAs(r, "pin-amy", ""); w.Code != http.StatusUnauthorized {
+			t.Fatalf("missing PIN: %d", w.Code)
+		}
+	}
+	for i := 0; i < pinMaxFailures; i++ {
+		if w := getAs(r, "pin-amy", "0000"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong PIN #%d: %d", i+1, w.Code)
+		}
+	}
+
+	// 連續輸錯後即使 PIN 正確也暫停驗證
+	w := getAs(r, "pin-amy", "2468")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("locked profile: %d %s (Retry-After %q)", w.Code, w.Body.String(), w.Header().Get("Retry-After"))
+	}
+
+	// 重設 PIN 後解除暫停，舊的 PIN 不再有效
+	if err := setProfilePIN("pin-amy", "1357"); err != nil {
+		t.Fatal(err)
+	}
+	if w := getAs(r, "pin-amy", "2468"); w.Code != http.StatusUnauthorized {
+		t.Errorf("old PIN after reset: %d", w.Code)
+	}
+	if w := getAs(r, "pin-amy", "1357"); w.Code != http.StatusOK {
+		t.Errorf("new PIN after reset: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestResolveOwnerPIN(t *testing.T) {
+	useTempDataDir(t)
+	r := newProfileRouter()
+
+	if w := getAs(r, "", ""); w.Code != http.StatusOK || w.Body.String() != `{"authenticated":false,"profile":"default"}` {
+		t.Fatalf("owner without PIN configured: %d %s", w.Code, w.Body.String())
+	}
+
+	t.Setenv(ownerPINEnv, "owner-pin")
+	t.Cleanup(func() { profilePINLimiter.reset(defaultProfile) })
+	if w := getAs(r, "", "wrong-pin"); w.Code != http.StatusUnauthorized {
+		t.Errorf("owner with wrong PIN: %d", w.Code)
+	}
+	if w := getAs(r, "", "owner-pin"); w.Code != http.StatusOK || w.Body.String() != `{"authenticated":true,"profile":"default"}` {
+		t.Errorf("owner with PIN: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPINLimiterBackoff(t *testing.T) {
+	limiter := &pinLimiter{attempts: make(map[string]*pinAttempts)}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < pinMaxFailures-1; i++ {
+		limiter.failed("amy", now)
+	}
+	if _, locked := limiter.locked("amy", now); locked {
+		t.Fatalf("locked after %d failures", pinMaxFailures-1)
+	}
+
+	limiter.failed("amy", now)
+	if wait, locked := limiter.locked("amy", now); !locked || wait != pinLockoutBase {
+		t.Fatalf("first lockout = %v %v, want %v", wait, locked, pinLockoutBase)
+	}
+	if _, locked := limiter.locked("amy", now.Add(pinLockoutBase)); locked {
+		t.Error("still locked after the lockout expired")
+	}
+
+	// 之後每次輸錯暫停時間加倍，最長 pinLockoutMax
+	limiter.failed("amy", now.Add(pinLockoutBase))
+	if wait, _ := limiter.locked("amy", now.Add(pinLockoutBase)); wait != 2*pinLockoutBase {
+		t.Errorf("second lockout = %v, want %v", wait, 2*pinLockoutBase)
+	}
+	for i := 0; i < 40; i++ {
+		limiter.failed("amy", now)
+	}
+	if wait, _ := limiter.locked("amy", now); wait != pinLockoutMax {
+		t.Errorf("lockout = %v, want at most %v", wait, pinLockoutMax)
+	}
+	if _, locked := limiter.locked("bob", now); locked {
+		t.Error("other profiles must not be locked")
+	}
+
+	// 長時間沒有再輸錯時重新計算
+	limiter.failed("amy", now.Add(pinFailureReset+time.Minute))
+	if _, locked := limiter.locked("amy", now.Add(pinFailureReset+time.Minute)); locked {
+		t.Error("failures older than pinFailureReset should be forgotten")
+	}
+}
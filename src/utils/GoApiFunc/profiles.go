@@ -0,0 +1,327 @@
+package GoApiFunc
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 多用戶設定：
+// 區域網內的其他裝置可透過 X-LexiQuest-Profile Header 或 ?profile= 指定用戶檔案，
+// 未指定或指定 default 時使用原本的 user.json，其餘用戶存放在 profilesDir/<名稱>.json；
+// 設定了 PIN 的用戶需同時提供 PIN（見 profile_pin.go）
+const (
+	profilesDir        = "./data/userdata/profiles"
+	defaultProfile     = "default"
+	profileHeader      = "X-LexiQuest-Profile"
+	profileContextKey  = "profile"
+	profileNameMaxRune = 32
+
+	roleTeacher = "teacher" // 可建立班級、指派作業與查看學生報告
+	roleStudent = "student"
+)
+
+// validProfileName 檢查用戶名稱：1～32 個字元，只允許文字、數字、- 與 _（可使用中文名稱）
+func validProfileName(name string) bool {
+	if name == "" || len([]rune(name)) > profileNameMaxRune {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// profileFilePath 回傳用戶的存檔路徑
+func profileFilePath(name string) string {
+	if name == "" || name == defaultProfile {
+		return userFilePath
+	}
+	return filepath.Join(profilesDir, name+".json")
+}
+
+// profileExists 判斷用戶檔案是否存在（default 永遠存在）
+func profileExists(name string) bool {
+	if name == defaultProfile {
+		return true
+	}
+	_, err := os.Stat(profileFilePath(name))
+	return err == nil
+}
+
+// ProfileMiddleware 讀取請求指定的用戶，名稱無效、用戶不存在或 PIN 錯誤時直接回傳錯誤
+func ProfileMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if status, msg := resolveProfile(c); status != http.StatusOK {
+			c.AbortWithStatusJSON(status, gin.H{"error": msg})
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalProfileMiddleware 與 ProfileMiddleware 相同，但驗證失敗時不中斷請求，視為未登入
+// 用於尚未有用戶也需要使用的路由（例如列出與建立用戶）
+func OptionalProfileMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resolveProfile(c)
+		c.Next()
+	}
+}
+
+// resolveProfile 驗證請求指定的用戶與 PIN（見 verifyProfilePIN），成功時記錄於 context 並回傳 200，否則回傳錯誤狀態與訊息
+func resolveProfile(c *gin.Context) (int, string) {
+	name := strings.TrimSpace(c.GetHeader(profileHeader))
+	if name == "" {
+		name = strings.TrimSpace(c.Query("profile"))
+	}
+	if name == "" {
+		name = defaultProfile
+	}
+	if !validProfileName(name) {
+		return http.StatusBadRequest, "無效的用戶名稱"
+	}
+	if !profileExists(name) {
+		return http.StatusNotFound, "找不到指定的用戶，請先建立用戶"
+	}
+	credential, err := profileCredential(name)
+	if err != nil {
+		return http.StatusInternalServerError, "無法讀取用戶資料"
+	}
+	if credential != nil {
+		if status, msg := verifyProfilePIN(c, name, credential, time.Now()); status != http.StatusOK {
+			return status, msg
+		}
+	}
+	c.Set(profileContextKey, name)
+	c.Set(profileAuthKey, credential != nil)
+	return http.StatusOK, ""
+}
+
+// validRole 檢查角色名稱（空值代表使用預設角色）
+func validRole(role string) bool {
+	return role == "" || role == roleTeacher || role == roleStudent
+}
+
+// profileRole 回傳用戶的角色；未設定時 default（伺服器擁有者）為老師，其餘用戶為學生
+func profileRole(name string, userData *UserData) string {
+	if userData.Role != "" {
+		return userData.Role
+	}
+	if name == defaultProfile {
+		return roleTeacher
+	}
+	return roleStudent
+}
+
+// requireTeacher 確認請求的用戶為老師且已通過 PIN 驗證，否則回傳 403 並回傳 false
+// 未指定用戶或用戶沒有設定 PIN 時，任何人都能以該身分發出請求，因此不具老師權限
+func requireTeacher(c *gin.Context) bool {
+	if !requestAuthenticated(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "老師操作需以設定了 PIN 的用戶登入"})
+		return false
+	}
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return false
+	}
+	if profileRole(requestProfile(c), userData) != roleTeacher {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有老師可以操作"})
+		return false
+	}
+	return true
+}
+
+// requestProfile 回傳請求指定的用戶名稱
+func requestProfile(c *gin.Context) string {
+	if name := c.GetString(profileContextKey); name != "" {
+		return name
+	}
+	return defaultProfile
+}
+
+// GetRequestUserData 讀取請求指定的用戶資料；未指定用戶時與 GetUserData 相同
+func GetRequestUserData(c *gin.Context) (*UserData, error) {
+	return loadProfileData(requestProfile(c))
+}
+
+// lockRequestProfile 鎖定請求指定用戶的存檔，回傳解鎖函式（見 lockUserFile）
+func lockRequestProfile(c *gin.Context) func() {
+	return lockProfile(requestProfile(c))
+}
+
+// lockProfile 鎖定指定用戶的存檔，回傳解鎖函式（見 lockUserFile）
+func lockProfile(name string) func() {
+	return lockUserFile(profileFilePath(name))
+}
+
+// GetRequestUserProgress 返回請求指定用戶的 Progress 部分
+func GetRequestUserProgress(c *gin.Context) (map[string]map[string]map[string]float64, error) {
+	data, err := GetRequestUserData(c)
+	if err != nil {
+		return nil, err
+	}
+	return data.Progress, nil
+}
+
+// loadProfileData 讀取指定用戶的資料
+func loadProfileData(name string) (*UserData, error) {
+	if name == defaultProfile {
+		return GetUserData()
+	}
+	return loadUserData(profileFilePath(name))
+}
+
+// listProfileNames 回傳所有用戶名稱（default 排在第一個）
+func listProfileNames() []string {
+	names := []string{defaultProfile}
+	entries, err := os.ReadDir(profilesDir)
+	if err != nil {
+		return names
+	}
+	var others []string
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || !validProfileName(name) || name == defaultProfile {
+			continue
+		}
+		others = append(others, name)
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+// ProfileSummary 為用戶列表中的一個用戶
+type ProfileSummary struct {
+	Profile     string `json:"profile"`
+	Username    string `json:"username"`
+	Leaderboard bool   `json:"leaderboard"` // 是否公開於排行榜
+	Role        string `json:"role"`        // teacher 或 student
+	HasPIN      bool   `json:"hasPin"`      // 是否已設定 PIN
+}
+
+// ListProfiles 列出伺服器上所有用戶
+func ListProfiles(c *gin.Context) {
+	profiles := []ProfileSummary{}
+	for _, name := range listProfileNames() {
+		userData, err := loadProfileData(name)
+		if err != nil {
+			continue
+		}
+		hasPIN := userData.Credential != nil || (name == defaultProfile && os.Getenv(ownerPINEnv) != "")
+		profiles = append(profiles, ProfileSummary{Profile: name, Username: userData.Username, Leaderboard: userData.Leaderboard, Role: profileRole(name, userData), HasPIN: hasPIN})
+	}
+	c.JSON(http.StatusOK, profiles)
+}
+
+// CreateProfileRequest 定義建立用戶的請求資料
+type CreateProfileRequest struct {
+	Profile  string `json:"profile"`  // 用戶名稱，用於 X-LexiQuest-Profile
+	Username string `json:"username"` // 顯示名稱，未提供時與 profile 相同
+	Role     string `json:"role"`     // teacher 或 student（預設）；只有老師可以建立老師
+	PIN      string `json:"pin"`      // 用戶 PIN（至少 4 個字元、最多 72 位元組），之後以 X-LexiQuest-PIN 證明身分
+}
+
+// CreateProfile 建立新的用戶檔案
+func CreateProfile(c *gin.Context) {
+	var req CreateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !validProfileName(req.Profile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的用戶名稱（文字、數字、- 或 _，最多 32 字）"})
+		return
+	}
+	if !validProfilePIN(req.PIN) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "PIN 需至少 4 個字元、最多 72 位元組且不含空白"})
+		return
+	}
+	if !validRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "角色只能是 teacher 或 student"})
+		return
+	}
+	if req.Role == roleTeacher && !requireTeacher(c) {
+		return
+	}
+	if req.Username == "" {
+		req.Username = req.Profile
+	}
+
+	// ✅ 鎖定後再檢查是否已存在，並一次寫入角色與 PIN，建立期間不會有沒有 PIN 的檔案
+	unlock := lockProfile(req.Profile)
+	defer unlock()
+	if profileExists(req.Profile) {
+		c.JSON(http.StatusConflict, gin.H{"error": "用戶已存在"})
+		return
+	}
+	credential, err := newProfileCredential(req.PIN)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法建立用戶"})
+		return
+	}
+	userData := &UserData{
+		Username:      req.Username,
+		Progress:      make(map[string]map[string]map[string]float64),
+		SchemaVersion: userSchemaVersion,
+		Role:          req.Role,
+		Credential:    credential,
+		path:          profileFilePath(req.Profile),
+	}
+	if err := SaveUserData(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法建立用戶"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "用戶建立成功", "profile": ProfileSummary{Profile: req.Profile, Username: req.Username, Role: profileRoleOrDefault(req.Profile, req.Role), HasPIN: true}})
+}
+
+// profileRoleOrDefault 回傳指定的角色，未指定時回傳用戶的預設角色
+func profileRoleOrDefault(name, role string) string {
+	return profileRole(name, &UserData{Role: role})
+}
+
+// setProfileRole 寫入用戶角色
+func setProfileRole(name, role string) error {
+	unlock := lockProfile(name)
+	defer unlock()
+	userData, err := loadProfileData(name)
+	if err != nil {
+		return err
+	}
+	userData.Role = role
+	return SaveUserData(userData)
+}
+
+// UpdateProfileRole 由老師設定其他用戶的角色（PUT /api/profiles/:profile/role，傳入 {"role": "teacher"}）
+func UpdateProfileRole(c *gin.Context) {
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Role == "" || !validRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "角色只能是 teacher 或 student"})
+		return
+	}
+	if !requireTeacher(c) {
+		return
+	}
+
+	name := c.Param("profile")
+	if !validProfileName(name) || !profileExists(name) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到指定的用戶"})
+		return
+	}
+	if name == requestProfile(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無法變更自己的角色"})
+		return
+	}
+	if err := setProfileRole(name, req.Role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法設定用戶角色"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "用戶角色已更新", "profile": name, "role": req.Role})
+}
@@ -3,7 +3,9 @@ package GoApiFunc
 import (
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,19 +14,25 @@ import (
 // decayFactor：每次練習後，單字權重乘以此因子（例如 0.9 表示權重下降至原來的 90%）
 // defaultWeight：未曾練習時的預設權重
 // minWeight：單字權重的最低值，避免降到 0
+// maxHistorySessions：user.json 中保留的測驗提交紀錄數量上限
 const (
-	decayFactor   = 0.9
-	defaultWeight = 10.0
-	minWeight     = 1.0
+	decayFactor        = 0.9
+	defaultWeight      = 10.0
+	minWeight          = 1.0
+	maxHistorySessions = 500
 )
 
-// SubmitQuiz 提交測驗結果（僅記錄練習次數，不區分正確與錯誤）
-// 請求的 Results 為練習過的單字列表
+// SubmitQuiz 提交測驗結果
+// Results 為答對（練習過）的單字列表；Answers 可額外帶入每個單字的對錯，
+// 答錯的單字會記錄為錯題，權重不衰減並往 defaultWeight 回升一級（見 applyLapse）
 func SubmitQuiz(c *gin.Context) {
 	var request struct {
-		Category string   `json:"category"`
-		Filename string   `json:"filename"`
-		Results  []string `json:"results"` // 例如 ["apple", "banana"]
+		Category string        `json:"category"`
+		Filename string        `json:"filename"`
+		Results  []string      `json:"results"` // 例如 ["apple", "banana"]
+		Answers  []QuizOutcome `json:"answers"` // 例如 [{"word": "apple", "correct": false}]
+		Seconds  int           `json:"seconds"` // 本次作答花費的秒數（選填，用於每日分鐘目標）
+		Mode     string        `json:"mode"`    // 測驗模式，dictation 時更新聽寫進度（選填）
 	}
 
 	// 🚀 Debug: 確保請求格式正確
@@ -33,20 +41,136 @@ func SubmitQuiz(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的測驗結果"})
 		return
 	}
+	if request.Mode != "" && request.Mode != quizModeDictation {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不支援的測驗模式: %s", request.Mode)})
+		return
+	}
 
-	// 讀取用戶進度（需由 user_progress.go 提供 GetUserProgress）
-	userProgress, err := GetUserProgress()
+	// 讀取完整用戶資料（進度、作答統計與提交紀錄都需要更新）
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	userData, err := GetRequestUserData(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶進度"})
 		return
 	}
 
-	// 確保指定題庫進度資料存在
-	EnsureCategoryAndFilename(userProgress, request.Category, request.Filename)
-
-	// 對每個練習過的單字進行乘法衰減更新
+	outcomes := make([]QuizOutcome, 0, len(request.Results)+len(request.Answers))
 	for _, word := range request.Results {
-		oldWeight := userProgress[request.Category][request.Filename][word]
+		outcomes = append(outcomes, QuizOutcome{Word: word, Correct: true})
+	}
+	outcomes = append(outcomes, request.Answers...)
+
+	duration := time.Duration(request.Seconds) * time.Second
+	session := recordQuizOutcomes(userData, request.Category, request.Filename, request.Mode, outcomes, duration, time.Now())
+
+	// 儲存更新後的進度
+	if err := SaveUserData(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法儲存學習進度"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "測驗結果已提交",
+		"sessionId": session.ID,
+		"progress":  modeProgress(userData, request.Mode, request.Category, request.Filename),
+	})
+}
+
+// recordQuizOutcomes 將一次測驗的作答結果寫入 userData：
+// 答對的單字權重乘法衰減，答錯的單字權重回升一級（最高為 defaultWeight），
+// 同時更新每個單字的作答統計、當日學習紀錄並加入提交紀錄（聽寫模式的權重與統計另外記錄，見 modeProgress、modeStats）
+func recordQuizOutcomes(userData *UserData, category, filename, mode string, outcomes []QuizOutcome, duration time.Duration, now time.Time) QuizSession {
+	progress := modeProgress(userData, mode, category, filename)
+	stats := modeStats(userData, mode, category, filename)
+	today := ensureDailyActivity(userData, now)
+	if duration > 0 {
+		today.Seconds += int(duration.Seconds())
+	}
+
+	// 記錄每個單字作答前的權重與統計，供復原提交使用
+	changes := make([]WeightChange, 0, len(outcomes))
+	recorded := make(map[string]bool)
+	for _, outcome := range outcomes {
+		if !recorded[outcome.Word] {
+			recorded[outcome.Word] = true
+			changes = append(changes, snapshotWord(userData, progress, stats, category, filename, outcome.Word))
+		}
+
+		// 拼字與聽寫進度中都沒有權重的單字才算當天新學的單字
+		if !wordPracticed(userData, category, filename, outcome.Word) {
+			today.NewWords++
+		}
+
+		if outcome.Correct {
+			applyPractice(progress, []string{outcome.Word})
+		} else {
+			applyLapse(progress, outcome.Word)
+		}
+
+		stat, exists := stats[outcome.Word]
+		if !exists {
+			stat = &WordStat{FirstSeen: now}
+			stats[outcome.Word] = stat
+		}
+		stat.Reviews++
+		stat.LastReviewed = now
+		today.Reviewed++
+		if outcome.Correct {
+			stat.Correct++
+			stat.CorrectStreak++
+			today.Correct++
+		} else {
+			lapse := now
+			stat.Lapses++
+			stat.CorrectStreak = 0
+			stat.LastLapse = &lapse
+		}
+	}
+
+	stampDailyGoal(today, userData.Goal)
+
+	for i := range changes {
+		changes[i].After = progress[changes[i].Word]
+	}
+
+	session := QuizSession{
+		ID:       newSessionID(),
+		Time:     now,
+		Category: category,
+		Filename: filename,
+		Mode:     mode,
+		Outcomes: outcomes,
+		Seconds:  int(duration.Seconds()),
+		Changes:  changes,
+	}
+	userData.History = append(userData.History, session)
+	if len(userData.History) > maxHistorySessions {
+		userData.History = userData.History[len(userData.History)-maxHistorySessions:]
+	}
+	// 較舊的提交只保留作答結果，避免 user.json 過大
+	for i := 0; i < len(userData.History)-maxUndoSessions; i++ {
+		userData.History[i].Changes = nil
+	}
+
+	return session
+}
+
+// ensureWordStats 確保作答統計中存在指定的分類與題庫，並回傳該題庫的統計
+func ensureWordStats(wordStats map[string]map[string]map[string]*WordStat, category, filename string) map[string]*WordStat {
+	if _, exists := wordStats[category]; !exists {
+		wordStats[category] = make(map[string]map[string]*WordStat)
+	}
+	if _, exists := wordStats[category][filename]; !exists {
+		wordStats[category][filename] = make(map[string]*WordStat)
+	}
+	return wordStats[category][filename]
+}
+
+// applyPractice 對練習過的單字進行乘法衰減更新
+func applyPractice(progress map[string]float64, words []string) {
+	for _, word := range words {
+		oldWeight := progress[word]
 		if oldWeight == 0 {
 			oldWeight = defaultWeight
 		}
@@ -54,19 +178,18 @@ func SubmitQuiz(c *gin.Context) {
 		if newWeight < minWeight {
 			newWeight = minWeight
 		}
-		userProgress[request.Category][request.Filename][word] = newWeight
+		progress[word] = newWeight
 	}
+}
 
-	// 儲存更新後的進度
-	if err := SaveUserProgress(userProgress); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法儲存學習進度"})
-		return
+// applyLapse 答錯時將單字權重除以 decayFactor（抵銷一次答對的衰減），最高回到 defaultWeight
+// 未曾練習的單字會記錄為 defaultWeight，讓權重只在答對時下降
+func applyLapse(progress map[string]float64, word string) {
+	oldWeight := progress[word]
+	if oldWeight == 0 {
+		oldWeight = defaultWeight
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":  "測驗結果已提交",
-		"progress": userProgress[request.Category][request.Filename],
-	})
+	progress[word] = math.Min(defaultWeight, oldWeight/decayFactor)
 }
 
 // DeleteWordlistProgress 刪除指定題庫的所有學習進度
@@ -74,11 +197,14 @@ func DeleteWordlistProgress(c *gin.Context) {
 	category := c.Param("category")
 	filename := c.Param("filename")
 
-	userProgress, err := GetUserProgress()
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	userData, err := GetRequestUserData(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶進度"})
 		return
 	}
+	userProgress := userData.Progress
 
 	// 確保類別和題庫存在
 	if _, exists := userProgress[category]; !exists {
@@ -99,7 +225,7 @@ func DeleteWordlistProgress(c *gin.Context) {
 	}
 
 	// 儲存變更
-	if err := SaveUserProgress(userProgress); err != nil {
+	if err := SaveUserData(userData); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法刪除學習進度"})
 		return
 	}
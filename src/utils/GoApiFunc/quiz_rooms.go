@@ -0,0 +1,703 @@
+package GoApiFunc
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 多人即時測驗（類似 Kahoot）：主持人建立房間，玩家以房間代碼加入，
+// 伺服器透過 SSE 同時推送題目給所有玩家，依作答時間計分並即時推送排名
+const (
+	roomCodeLength      = 6
+	roomCodeAlphabet    = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // 去除容易混淆的 0/O、1/I
+	roomDefaultLimit    = 10
+	roomDefaultSeconds  = 20
+	roomMaxSeconds      = 300
+	roomMaxPlayers      = 100
+	roomIdleTimeout     = 2 * time.Hour          // 沒有任何動作的房間保留時間
+	roomReapInterval    = 10 * time.Minute       // 清除閒置房間的檢查間隔
+	roomKeepAlive       = 15 * time.Second       // SSE 保持連線的 ping 間隔
+	roomProgressDelay   = 500 * time.Millisecond // 作答人數與排名的推送間隔，同一段時間內的作答合併為一次推送
+	roomSubscriberQueue = 256                    // 每個連線的事件佇列長度（玩家加入與作答進度的事件），塞滿時中斷連線讓前端重新連線
+
+	roomStateLobby    = "lobby"    // 等待玩家加入
+	roomStateQuestion = "question" // 題目作答中
+	roomStateReveal   = "reveal"   // 公布答案與排名，等待主持人進入下一題
+	roomStateFinished = "finished" // 測驗結束
+)
+
+// roomEvent 為推送給房間內所有連線的 SSE 事件
+type roomEvent struct {
+	Name string
+	Data any
+}
+
+// roomAnswer 記錄玩家單題的作答（伺服器端時間戳記）
+type roomAnswer struct {
+	Answer     string    `json:"answer"`
+	Correct    bool      `json:"correct"`
+	TimedOut   bool      `json:"timedOut"`
+	AnsweredAt time.Time `json:"answeredAt"`
+	ElapsedMs  int64     `json:"elapsedMs"`
+	Points     int       `json:"points"`
+}
+
+// roomPlayer 為房間內的玩家
+type roomPlayer struct {
+	ID       string
+	Name     string
+	Token    string
+	JoinedAt time.Time
+	Answers  map[int]*roomAnswer
+}
+
+// RoomRanking 為排名中的一位玩家
+type RoomRanking struct {
+	Rank      int    `json:"rank"`
+	PlayerID  string `json:"playerId"`
+	Name      string `json:"name"`
+	Score     int    `json:"score"`
+	Correct   int    `json:"correct"`
+	ElapsedMs int64  `json:"elapsedMs"` // 答對題目的總用時，分數相同時用時短者優先
+}
+
+// quizRoom 為一個測驗房間，所有欄位都由 mu 保護
+type quizRoom struct {
+	mu sync.Mutex
+
+	Code         string
+	HostToken    string
+	Category     string
+	Filename     string
+	Seconds      int
+	Questions    []challengeQuestion
+	State        string
+	Current      int // 目前題號，尚未開始時為 -1
+	QuestionAt   time.Time
+	CreatedAt    time.Time
+	LastActivity time.Time
+	Players      map[string]*roomPlayer
+	playerOrder  []string // 加入順序，讓排名與列表穩定
+	subscribers  map[chan roomEvent]bool
+	progressDue  bool // 已排定推送作答人數與排名（見 scheduleProgress）
+}
+
+// roomManager 管理所有房間，rooms map 由 mu 保護；房間內的狀態由各房間自己的鎖保護
+type roomManager struct {
+	mu    sync.Mutex
+	rooms map[string]*quizRoom
+}
+
+var quizRooms = &roomManager{rooms: make(map[string]*quizRoom)}
+
+// randomToken 產生指定長度、由 alphabet 組成的隨機字串
+func randomToken(alphabet string, length int) string {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return newSessionID()[:length]
+	}
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return string(b)
+}
+
+// add 以不重複的房間代碼登記房間
+func (m *roomManager) add(room *quizRoom, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for {
+		code := randomToken(roomCodeAlphabet, roomCodeLength)
+		if _, exists := m.rooms[code]; !exists {
+			room.Code = code
+			m.rooms[code] = room
+			return
+		}
+	}
+}
+
+// reap 清除閒置超過 roomIdleTimeout 的房間並中斷其連線，回傳清除的房間數
+func (m *roomManager) reap(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for code, r := range m.rooms {
+		r.mu.Lock()
+		idle := now.Sub(r.LastActivity) > roomIdleTimeout
+		if idle {
+			r.closeSubscribers()
+		}
+		r.mu.Unlock()
+		if idle {
+			delete(m.rooms, code)
+			removed++
+		}
+	}
+	return removed
+}
+
+// startReaper 在背景定期清除閒置房間，回傳停止函式
+func (m *roomManager) startReaper(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case now := <-ticker.C:
+				if removed := m.reap(now); removed > 0 {
+					fmt.Println("🧹 已清除閒置的測驗房間:", removed)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// StartRoomReaper 啟動清除閒置房間的背景工作（伺服器啟動時呼叫），回傳停止函式
+func StartRoomReaper() func() {
+	return quizRooms.startReaper(roomReapInterval)
+}
+
+// get 依代碼取得房間（不分大小寫）
+func (m *roomManager) get(code string) *quizRoom {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rooms[strings.ToUpper(strings.TrimSpace(code))]
+}
+
+// broadcast 推送事件給所有連線（呼叫前需持有 r.mu）；佇列已滿的連線會被中斷，前端重新連線後會收到完整狀態
+func (r *quizRoom) broadcast(name string, data any) {
+	event := roomEvent{Name: name, Data: data}
+	for ch := range r.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe 建立新的事件連線，第一個事件為房間目前的完整狀態
+func (r *quizRoom) subscribe(playerID string) chan roomEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ch := make(chan roomEvent, roomSubscriberQueue)
+	ch <- roomEvent{Name: "state", Data: r.snapshot(playerID, time.Now())}
+	r.subscribers[ch] = true
+	return ch
+}
+
+// unsubscribe 移除事件連線（可能已因佇列塞滿而被移除）
+func (r *quizRoom) unsubscribe(ch chan roomEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.subscribers[ch] {
+		delete(r.subscribers, ch)
+		close(ch)
+	}
+}
+
+// closeSubscribers 中斷所有連線（呼叫前需持有 r.mu）
+func (r *quizRoom) closeSubscribers() {
+	for ch := range r.subscribers {
+		delete(r.subscribers, ch)
+		close(ch)
+	}
+}
+
+// deadline 回傳目前題目的截止時間
+func (r *quizRoom) deadline() time.Time {
+	return r.QuestionAt.Add(time.Duration(r.Seconds) * time.Second)
+}
+
+// questionPayload 為推送給玩家的題目（不含答案）
+func (r *quizRoom) questionPayload() gin.H {
+	q := r.Questions[r.Current]
+	return gin.H{
+		"index":       r.Current,
+		"total":       len(r.Questions),
+		"translation": q.Translation,
+		"type":        q.Type,
+		"startedAt":   r.QuestionAt,
+		"deadline":    r.deadline(),
+		"seconds":     r.Seconds,
+	}
+}
+
+// rankings 依總分、答對數與答對總用時計算排名（呼叫前需持有 r.mu）
+func (r *quizRoom) rankings() []RoomRanking {
+	rankings := make([]RoomRanking, 0, len(r.Players))
+	for _, id := range r.playerOrder {
+		player := r.Players[id]
+		ranking := RoomRanking{PlayerID: player.ID, Name: player.Name}
+		for _, answer := range player.Answers {
+			ranking.Score += answer.Points
+			if answer.Correct && !answer.TimedOut {
+				ranking.Correct++
+				ranking.ElapsedMs += answer.ElapsedMs
+			}
+		}
+		rankings = append(rankings, ranking)
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		a, b := rankings[i], rankings[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Correct != b.Correct {
+			return a.Correct > b.Correct
+		}
+		return a.ElapsedMs < b.ElapsedMs
+	})
+	for i := range rankings {
+		prev := i - 1
+		if prev >= 0 && rankings[i].Score == rankings[prev].Score && rankings[i].Correct == rankings[prev].Correct && rankings[i].ElapsedMs == rankings[prev].ElapsedMs {
+			rankings[i].Rank = rankings[prev].Rank
+		} else {
+			rankings[i].Rank = i + 1
+		}
+	}
+	return rankings
+}
+
+// answeredCount 回傳目前題目已作答的玩家數（呼叫前需持有 r.mu）
+func (r *quizRoom) answeredCount() int {
+	count := 0
+	for _, player := range r.Players {
+		if _, answered := player.Answers[r.Current]; answered {
+			count++
+		}
+	}
+	return count
+}
+
+// snapshot 回傳房間目前的狀態；playerID 不為空時附上該玩家本題的作答（呼叫前需持有 r.mu）
+func (r *quizRoom) snapshot(playerID string, now time.Time) gin.H {
+	players := make([]gin.H, 0, len(r.playerOrder))
+	for _, id := range r.playerOrder {
+		players = append(players, gin.H{"playerId": id, "name": r.Players[id].Name})
+	}
+	state := gin.H{
+		"code":      r.Code,
+		"category":  r.Category,
+		"filename":  r.Filename,
+		"state":     r.State,
+		"total":     len(r.Questions),
+		"seconds":   r.Seconds,
+		"players":   players,
+		"rankings":  r.rankings(),
+		"serverNow": now,
+	}
+	if r.State == roomStateQuestion || r.State == roomStateReveal {
+		state["question"] = r.questionPayload()
+		state["answered"] = r.answeredCount()
+	}
+	if r.State == roomStateReveal {
+		state["expected"] = r.Questions[r.Current].Word
+	}
+	if player := r.Players[playerID]; player != nil {
+		if answer, answered := player.Answers[r.Current]; answered {
+			state["myAnswer"] = answer
+		}
+	}
+	return state
+}
+
+// startQuestion 推送第 index 題並在截止時自動公布答案（呼叫前需持有 r.mu）
+func (r *quizRoom) startQuestion(index int, now time.Time) {
+	r.State = roomStateQuestion
+	r.Current = index
+	r.QuestionAt = now
+	r.LastActivity = now
+	r.broadcast("question", r.questionPayload())
+
+	time.AfterFunc(time.Duration(r.Seconds)*time.Second, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.State == roomStateQuestion && r.Current == index {
+			r.reveal()
+		}
+	})
+}
+
+// reveal 公布目前題目的答案與排名（呼叫前需持有 r.mu）
+func (r *quizRoom) reveal() {
+	r.State = roomStateReveal
+	r.broadcast("reveal", gin.H{
+		"index":    r.Current,
+		"expected": r.Questions[r.Current].Word,
+		"answered": r.answeredCount(),
+		"rankings": r.rankings(),
+	})
+}
+
+// finish 結束測驗並推送最終排名（呼叫前需持有 r.mu）
+func (r *quizRoom) finish() {
+	r.State = roomStateFinished
+	r.broadcast("finished", gin.H{"rankings": r.rankings()})
+}
+
+// submitAnswer 以伺服器時間記錄作答並計分；所有玩家都作答後立即公布答案（呼叫前需持有 r.mu）
+func (r *quizRoom) submitAnswer(player *roomPlayer, answer string, now time.Time) *roomAnswer {
+	elapsed := now.Sub(r.QuestionAt)
+	allowed := time.Duration(r.Seconds) * time.Second
+	result := &roomAnswer{
+		Answer:     answer,
+		Correct:    normalizeAnswer(answer) == normalizeAnswer(r.Questions[r.Current].Word),
+		TimedOut:   now.After(r.deadline()),
+		AnsweredAt: now,
+		ElapsedMs:  elapsed.Milliseconds(),
+	}
+	if result.Correct && !result.TimedOut {
+		result.Points = timedPoints(elapsed, allowed)
+	}
+	player.Answers[r.Current] = result
+	r.LastActivity = now
+
+	if r.answeredCount() == len(r.Players) {
+		r.reveal()
+	} else {
+		r.scheduleProgress()
+	}
+	return result
+}
+
+// scheduleProgress 排定在 roomProgressDelay 後推送作答人數與排名（呼叫前需持有 r.mu）；
+// 同一段時間內的多筆作答只推送一次，避免全班同時作答時每筆作答都推送完整排名給所有連線
+func (r *quizRoom) scheduleProgress() {
+	if r.progressDue {
+		return
+	}
+	r.progressDue = true
+	time.AfterFunc(roomProgressDelay, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.progressDue = false
+		// 已公布答案時不再推送，reveal 事件已包含作答人數與排名；期間進入下一題時推送新題目的進度
+		if r.State == roomStateQuestion {
+			r.broadcast("answered", gin.H{"index": r.Current, "answered": r.answeredCount(), "players": len(r.Players)})
+			r.broadcast("rankings", r.rankings())
+		}
+	})
+}
+
+// findRoom 取得路徑中的房間，不存在時回傳 404
+func findRoom(c *gin.Context) *quizRoom {
+	room := quizRooms.get(c.Param("code"))
+	if room == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "房間不存在或已關閉"})
+	}
+	return room
+}
+
+// CreateRoomRequest 定義建立房間的請求資料
+type CreateRoomRequest struct {
+	Category string `json:"category"`
+	Filename string `json:"filename"`
+	Limit    int    `json:"limit"`   // 題數，預設 10
+	Seconds  int    `json:"seconds"` // 每題秒數，預設 20
+	Seed     int64  `json:"seed"`    // 亂數種子，未提供時隨機產生
+}
+
+// CreateRoom 建立測驗房間，回傳房間代碼與主持人權杖（開始、下一題、結束時使用）
+func CreateRoom(c *gin.Context) {
+	var req CreateRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Category == "" || req.Filename == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的題庫與房間設定"})
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = roomDefaultLimit
+	}
+	if req.Seconds <= 0 {
+		req.Seconds = roomDefaultSeconds
+	}
+	if req.Seconds > roomMaxSeconds {
+		req.Seconds = roomMaxSeconds
+	}
+	if req.Seed == 0 {
+		req.Seed = time.Now().UnixNano()
+	}
+
+	if !validWordlistRef(req.Category, req.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "題庫名稱不合法"})
+		return
+	}
+	words, err := parseWordlistFile(filepath.Join(wordlistPath, req.Category, req.Filename+".txt"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到指定的題庫"})
+		return
+	}
+	if len(words) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "題庫為空，無可用單字"})
+		return
+	}
+
+	now := time.Now()
+	room := &quizRoom{
+		HostToken:    newSessionID(),
+		Category:     req.Category,
+		Filename:     req.Filename,
+		Seconds:      req.Seconds,
+		Questions:    challengeQuestions(words, req.Seed, req.Limit),
+		State:        roomStateLobby,
+		Current:      -1,
+		CreatedAt:    now,
+		LastActivity: now,
+		Players:      make(map[string]*roomPlayer),
+		subscribers:  make(map[chan roomEvent]bool),
+	}
+	quizRooms.add(room, now)
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"code": room.Code, "hostToken": room.HostToken, "room": room.snapshot("", now)})
+}
+
+// JoinRoom 以名稱加入房間，回傳玩家權杖（作答與接收事件時使用）
+func JoinRoom(c *gin.Context) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供玩家名稱"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+
+	room := findRoom(c)
+	if room == nil {
+		return
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.State == roomStateFinished {
+		c.JSON(http.StatusConflict, gin.H{"error": "測驗已結束"})
+		return
+	}
+	if len(room.Players) >= roomMaxPlayers {
+		c.JSON(http.StatusConflict, gin.H{"error": "房間人數已滿"})
+		return
+	}
+	for _, player := range room.Players {
+		if strings.EqualFold(player.Name, name) {
+			c.JSON(http.StatusConflict, gin.H{"error": "名稱已被使用"})
+			return
+		}
+	}
+
+	now := time.Now()
+	player := &roomPlayer{
+		ID:       newSessionID(),
+		Name:     name,
+		Token:    newSessionID(),
+		JoinedAt: now,
+		Answers:  make(map[int]*roomAnswer),
+	}
+	room.Players[player.ID] = player
+	room.playerOrder = append(room.playerOrder, player.ID)
+	room.LastActivity = now
+	room.broadcast("player_joined", gin.H{"playerId": player.ID, "name": player.Name, "players": len(room.Players)})
+
+	c.JSON(http.StatusOK, gin.H{"playerId": player.ID, "playerToken": player.Token, "room": room.snapshot(player.ID, now)})
+}
+
+// GetRoom 取得房間目前的狀態（SSE 斷線時可用來補齊狀態）
+func GetRoom(c *gin.Context) {
+	room := findRoom(c)
+	if room == nil {
+		return
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	c.JSON(http.StatusOK, room.snapshot(room.playerByToken(c.Query("token")), time.Now()))
+}
+
+// playerByToken 依權杖找出玩家 ID，找不到時回傳空字串（呼叫前需持有 r.mu）
+func (r *quizRoom) playerByToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	for id, player := range r.Players {
+		if player.Token == token {
+			return id
+		}
+	}
+	return ""
+}
+
+// RoomEvents 以 SSE 推送房間事件：state、player_joined、question、answered、rankings、reveal、finished
+// EventSource 無法自訂 Header，因此以 ?token= 傳入主持人或玩家權杖
+func RoomEvents(c *gin.Context) {
+	room := findRoom(c)
+	if room == nil {
+		return
+	}
+	token := c.Query("token")
+	room.mu.Lock()
+	playerID := room.playerByToken(token)
+	allowed := playerID != "" || (token != "" && token == room.HostToken)
+	room.mu.Unlock()
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "請提供有效的房間權杖"})
+		return
+	}
+
+	events := room.subscribe(playerID)
+	defer room.unsubscribe(events)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Name, event.Data)
+			return true
+		case <-time.After(roomKeepAlive):
+			c.SSEvent("ping", time.Now())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// hostRoom 取得房間並確認主持人權杖，失敗時回傳錯誤；成功時房間已上鎖，呼叫端需解鎖
+func hostRoom(c *gin.Context) *quizRoom {
+	var req struct {
+		HostToken string `json:"hostToken"`
+	}
+	c.ShouldBindJSON(&req)
+
+	room := findRoom(c)
+	if room == nil {
+		return nil
+	}
+	room.mu.Lock()
+	if req.HostToken == "" || req.HostToken != room.HostToken {
+		room.mu.Unlock()
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有主持人可以操作"})
+		return nil
+	}
+	return room
+}
+
+// StartRoom 主持人開始測驗，推送第一題
+func StartRoom(c *gin.Context) {
+	room := hostRoom(c)
+	if room == nil {
+		return
+	}
+	defer room.mu.Unlock()
+
+	if room.State != roomStateLobby {
+		c.JSON(http.StatusConflict, gin.H{"error": "測驗已經開始"})
+		return
+	}
+	room.startQuestion(0, time.Now())
+	c.JSON(http.StatusOK, gin.H{"question": room.questionPayload()})
+}
+
+// NextRoomQuestion 主持人進入下一題；作答中呼叫時先公布目前題目的答案，最後一題之後結束測驗
+func NextRoomQuestion(c *gin.Context) {
+	room := hostRoom(c)
+	if room == nil {
+		return
+	}
+	defer room.mu.Unlock()
+
+	switch room.State {
+	case roomStateLobby:
+		c.JSON(http.StatusConflict, gin.H{"error": "測驗尚未開始"})
+		return
+	case roomStateFinished:
+		c.JSON(http.StatusConflict, gin.H{"error": "測驗已結束"})
+		return
+	case roomStateQuestion:
+		room.reveal()
+	}
+
+	if room.Current+1 >= len(room.Questions) {
+		room.finish()
+		c.JSON(http.StatusOK, gin.H{"state": room.State, "rankings": room.rankings()})
+		return
+	}
+	room.startQuestion(room.Current+1, time.Now())
+	c.JSON(http.StatusOK, gin.H{"state": room.State, "question": room.questionPayload()})
+}
+
+// FinishRoom 主持人提前結束測驗並推送最終排名
+func FinishRoom(c *gin.Context) {
+	room := hostRoom(c)
+	if room == nil {
+		return
+	}
+	defer room.mu.Unlock()
+
+	if room.State != roomStateFinished {
+		room.finish()
+	}
+	c.JSON(http.StatusOK, gin.H{"state": room.State, "rankings": room.rankings()})
+}
+
+// AnswerRoomQuestion 玩家作答目前的題目，以伺服器收到的時間計算用時與得分
+func AnswerRoomQuestion(c *gin.Context) {
+	var req struct {
+		PlayerToken string `json:"playerToken"`
+		Index       int    `json:"index"`
+		Answer      string `json:"answer"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的作答資料"})
+		return
+	}
+	now := time.Now()
+
+	room := findRoom(c)
+	if room == nil {
+		return
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	player := room.Players[room.playerByToken(req.PlayerToken)]
+	if player == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "請提供有效的玩家權杖"})
+		return
+	}
+	if room.State != roomStateQuestion || req.Index != room.Current {
+		c.JSON(http.StatusConflict, gin.H{"error": "此題目前不開放作答"})
+		return
+	}
+	if _, answered := player.Answers[room.Current]; answered {
+		c.JSON(http.StatusConflict, gin.H{"error": "此題已作答"})
+		return
+	}
+
+	c.JSON(http.StatusOK, room.submitAnswer(player, req.Answer, now))
+}
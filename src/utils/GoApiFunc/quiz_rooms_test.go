@@ -0,0 +1,404 @@
+package GoApiFunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRoom 建立不依賴題庫檔案的房間並登記到 quizRooms，測試結束時移除
+func newTestRoom(t *testing.T, seconds int, words ...string) *quizRoom {
+	t.Helper()
+	questions := make([]challengeQuestion, 0, len(words))
+	for _, word := range words {
+		questions = append(questions, challengeQuestion{Word: word, Translation: word + "-zh", Type: "noun"})
+	}
+	now := time.Now()
+	room := &quizRoom{
+		HostToken:    newSessionID(),
+		Category:     "test",
+		Filename:     "rooms",
+		Seconds:      seconds,
+		Questions:    questions,
+		State:        roomStateLobby,
+		Current:      -1,
+		CreatedAt:    now,
+		LastActivity: now,
+		Players:      make(map[string]*roomPlayer),
+		subscribers:  make(map[chan roomEvent]bool),
+	}
+	quizRooms.add(room, now)
+	t.Cleanup(func() {
+		quizRooms.mu.Lock()
+		delete(quizRooms.rooms, room.Code)
+		quizRooms.mu.Unlock()
+	})
+	return room
+}
+
+// newRoomRouter 註冊房間相關的路由
+func newRoomRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/rooms/:code/join", JoinRoom)
+	r.POST("/rooms/:code/start", StartRoom)
+	r.POST("/rooms/:code/next", NextRoomQuestion)
+	r.POST("/rooms/:code/answer", AnswerRoomQuestion)
+	return r
+}
+
+// postJSON 送出 JSON 請求並解析回應
+func postJSON(t *testing.T, r http.Handler, path string, body any, out any) int {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Errorf("%s: invalid response %q: %v", path, w.Body.String(), err)
+		}
+	}
+	return w.Code
+}
+
+// waitEvent 等待指定名稱的事件，回傳期間收到的其他事件名稱
+func waitEvent(t *testing.T, events chan roomEvent, name string, timeout time.Duration) (roomEvent, []string) {
+	t.Helper()
+	var seen []string
+	deadline := time.After(timeout)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("event stream closed while waiting for %q (seen %v)", name, seen)
+			}
+			if event.Name == name {
+				return event, seen
+			}
+			seen = append(seen, event.Name)
+		case <-deadline:
+			t.Fatalf("timed out waiting for %q (seen %v)", name, seen)
+		}
+	}
+}
+
+func countEvents(names []string, name string) int {
+	count := 0
+	for _, n := range names {
+		if n == name {
+			count++
+		}
+	}
+	return count
+}
+
+func TestRoomConcurrentPlayers(t *testing.T) {
+	const players = 40
+	room := newTestRoom(t, 30, "apple", "book")
+	router := newRoomRouter()
+	base := "/rooms/" + room.Code
+
+	type joined struct {
+		ID     string
+		Token  string
+		Events chan roomEvent
+	}
+	joinedPlayers := make([]joined, players)
+
+	var wg sync.WaitGroup
+	for i := 0; i < players; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var resp struct {
+				PlayerID    string `json:"playerId"`
+				PlayerToken string `json:"playerToken"`
+			}
+			if code := postJSON(t, router, base+"/join", gin.H{"name": fmt.Sprintf("player-%d", i)}, &resp); code != http.StatusOK {
+				t.Errorf("join %d: status %d", i, code)
+				return
+			}
+			joinedPlayers[i] = joined{ID: resp.PlayerID, Token: resp.PlayerToken, Events: room.subscribe(resp.PlayerID)}
+		}(i)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	if code := postJSON(t, router, base+"/start", gin.H{"hostToken": room.HostToken}, nil); code != http.StatusOK {
+		t.Fatalf("start: status %d", code)
+	}
+
+	// ✅ 偶數號玩家答對、奇數號答錯，全部同時作答
+	start := time.Now()
+	for i := range joinedPlayers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			answer := "apple"
+			if i%2 == 1 {
+				answer = "wrong"
+			}
+			var result roomAnswer
+			if code := postJSON(t, router, base+"/answer", gin.H{"playerToken": joinedPlayers[i].Token, "index": 0, "answer": answer}, &result); code != http.StatusOK {
+				t.Errorf("answer %d: status %d", i, code)
+				return
+			}
+			if result.Correct != (i%2 == 0) {
+				t.Errorf("answer %d: correct = %v", i, result.Correct)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// 所有人作答後立即公布答案，不必等到 30 秒截止
+	for i, player := range joinedPlayers {
+		event, seen := waitEvent(t, player.Events, "reveal", 2*time.Second)
+		data := event.Data.(gin.H)
+		if data["answered"] != players {
+			t.Errorf("player %d: reveal answered = %v, want %d", i, data["answered"], players)
+		}
+		// 作答進度合併推送：全部作答在推送間隔內完成，不會每筆作答都推送一次排名
+		if n := countEvents(seen, "rankings"); n > 1 {
+			t.Errorf("player %d: received %d rankings events before reveal", i, n)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("reveal took %v", elapsed)
+	}
+
+	room.mu.Lock()
+	state := room.State
+	rankings := room.rankings()
+	room.mu.Unlock()
+	if state != roomStateReveal {
+		t.Fatalf("state = %q, want %q", state, roomStateReveal)
+	}
+	if len(rankings) != players {
+		t.Fatalf("rankings = %d, want %d", len(rankings), players)
+	}
+	for i, ranking := range rankings {
+		wantCorrect := 0
+		if i < players/2 {
+			wantCorrect = 1
+		}
+		if ranking.Correct != wantCorrect {
+			t.Errorf("rankings[%d].Correct = %d, want %d", i, ranking.Correct, wantCorrect)
+		}
+		if i > 0 {
+			prev := rankings[i-1]
+			if prev.Score < ranking.Score || (prev.Score == ranking.Score && prev.Rank > ranking.Rank) {
+				t.Errorf("rankings out of order at %d: %+v before %+v", i, prev, ranking)
+			}
+		}
+	}
+
+	// 公布答案後不再接受作答
+	if code := postJSON(t, router, base+"/answer", gin.H{"playerToken": joinedPlayers[0].Token, "index": 0, "answer": "apple"}, nil); code != http.StatusConflict {
+		t.Errorf("answer after reveal: status %d, want %d", code, http.StatusConflict)
+	}
+
+	for _, player := range joinedPlayers {
+		room.unsubscribe(player.Events)
+	}
+}
+
+func TestRoomRankingsOrder(t *testing.T) {
+	room := newTestRoom(t, 10, "apple", "book")
+	start := time.Now()
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	names := []string{"slow", "fast", "wrong", "tie-a", "tie-b"}
+	for _, name := range names {
+		room.Players[name] = &roomPlayer{ID: name, Name: name, Answers: make(map[int]*roomAnswer)}
+		room.playerOrder = append(room.playerOrder, name)
+	}
+	room.startQuestion(0, start)
+	room.submitAnswer(room.Players["slow"], "apple", start.Add(8*time.Second))
+	room.submitAnswer(room.Players["fast"], "Apple ", start.Add(1*time.Second))
+	room.submitAnswer(room.Players["wrong"], "banana", start.Add(500*time.Millisecond))
+	room.submitAnswer(room.Players["tie-a"], "apple", start.Add(4*time.Second))
+	room.submitAnswer(room.Players["tie-b"], "apple", start.Add(4*time.Second))
+
+	rankings := room.rankings()
+	want := []struct {
+		id   string
+		rank int
+	}{{"fast", 1}, {"tie-a", 2}, {"tie-b", 2}, {"slow", 4}, {"wrong", 5}}
+	for i, w := range want {
+		if rankings[i].PlayerID != w.id || rankings[i].Rank != w.rank {
+			t.Errorf("rankings[%d] = %s (rank %d), want %s (rank %d)", i, rankings[i].PlayerID, rankings[i].Rank, w.id, w.rank)
+		}
+	}
+	if rankings[4].Score != 0 || rankings[4].Correct != 0 {
+		t.Errorf("wrong answer scored: %+v", rankings[4])
+	}
+	if room.State != roomStateReveal {
+		t.Errorf("state = %q after everyone answered, want %q", room.State, roomStateReveal)
+	}
+}
+
+func TestRoomLateAnswers(t *testing.T) {
+	room := newTestRoom(t, 1, "apple")
+	router := newRoomRouter()
+	base := "/rooms/" + room.Code
+
+	var first, second struct {
+		PlayerID    string `json:"playerId"`
+		PlayerToken string `json:"playerToken"`
+	}
+	postJSON(t, router, base+"/join", gin.H{"name": "first"}, &first)
+	postJSON(t, router, base+"/join", gin.H{"name": "second"}, &second)
+	events := room.subscribe("")
+	defer room.unsubscribe(events)
+
+	if code := postJSON(t, router, base+"/start", gin.H{"hostToken": room.HostToken}, nil); code != http.StatusOK {
+		t.Fatalf("start: status %d", code)
+	}
+
+	// 伺服器在截止後才收到的答案：記錄但不計分
+	room.mu.Lock()
+	late := room.submitAnswer(room.Players[first.PlayerID], "apple", room.deadline().Add(200*time.Millisecond))
+	room.mu.Unlock()
+	if !late.TimedOut || late.Points != 0 {
+		t.Errorf("late answer = %+v, want timed out with 0 points", late)
+	}
+
+	// 沒有作答的玩家：時間到時自動公布答案
+	event, _ := waitEvent(t, events, "reveal", 3*time.Second)
+	if data := event.Data.(gin.H); data["answered"] != 1 || data["expected"] != "apple" {
+		t.Errorf("reveal = %v", data)
+	}
+	if code := postJSON(t, router, base+"/answer", gin.H{"playerToken": second.PlayerToken, "index": 0, "answer": "apple"}, nil); code != http.StatusConflict {
+		t.Errorf("answer after timeout: status %d, want %d", code, http.StatusConflict)
+	}
+
+	room.mu.Lock()
+	rankings := room.rankings()
+	room.mu.Unlock()
+	for _, ranking := range rankings {
+		if ranking.Score != 0 || ranking.Correct != 0 {
+			t.Errorf("ranking after timeout = %+v, want no score", ranking)
+		}
+	}
+}
+
+func TestRoomProgressCoalesced(t *testing.T) {
+	room := newTestRoom(t, 30, "apple")
+	room.mu.Lock()
+	for _, name := range []string{"a", "b", "c"} {
+		room.Players[name] = &roomPlayer{ID: name, Name: name, Answers: make(map[int]*roomAnswer)}
+		room.playerOrder = append(room.playerOrder, name)
+	}
+	room.mu.Unlock()
+	events := room.subscribe("")
+	defer room.unsubscribe(events)
+
+	room.mu.Lock()
+	now := time.Now()
+	room.startQuestion(0, now)
+	room.submitAnswer(room.Players["a"], "apple", now.Add(time.Second))
+	room.submitAnswer(room.Players["b"], "nope", now.Add(time.Second))
+	room.mu.Unlock()
+
+	event, seen := waitEvent(t, events, "answered", 2*time.Second)
+	if countEvents(seen, "answered") != 0 {
+		t.Errorf("answered events sent before the coalesced one: %v", seen)
+	}
+	if data := event.Data.(gin.H); data["answered"] != 2 {
+		t.Errorf("answered = %v, want 2", data["answered"])
+	}
+	rankings, _ := waitEvent(t, events, "rankings", time.Second)
+	if got := rankings.Data.([]RoomRanking); len(got) != 3 || got[0].PlayerID != "a" {
+		t.Errorf("rankings = %+v", got)
+	}
+
+	select {
+	case extra := <-events:
+		t.Errorf("unexpected extra event %q", extra.Name)
+	case <-time.After(2 * roomProgressDelay):
+	}
+}
+
+func TestRoomManagerAddUniqueCodes(t *testing.T) {
+	m := &roomManager{rooms: make(map[string]*quizRoom)}
+	const rooms = 500
+
+	var wg sync.WaitGroup
+	for i := 0; i < rooms; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.add(&quizRoom{LastActivity: time.Now(), subscribers: make(map[chan roomEvent]bool)}, time.Now())
+		}()
+	}
+	wg.Wait()
+
+	if len(m.rooms) != rooms {
+		t.Fatalf("rooms = %d, want %d (duplicate codes)", len(m.rooms), rooms)
+	}
+	for code, room := range m.rooms {
+		if room.Code != code || len(code) != roomCodeLength {
+			t.Errorf("room code %q stored under %q", room.Code, code)
+		}
+		for _, r := range code {
+			if !strings.ContainsRune(roomCodeAlphabet, r) {
+				t.Errorf("code %q contains %q", code, r)
+			}
+		}
+		if got := m.get(strings.ToLower(code)); got != room {
+			t.Errorf("get(%q) did not find the room", strings.ToLower(code))
+		}
+	}
+}
+
+func TestRoomManagerReap(t *testing.T) {
+	m := &roomManager{rooms: make(map[string]*quizRoom)}
+	now := time.Now()
+	idle := &quizRoom{LastActivity: now.Add(-roomIdleTimeout - time.Minute), subscribers: make(map[chan roomEvent]bool)}
+	active := &quizRoom{LastActivity: now.Add(-time.Minute), subscribers: make(map[chan roomEvent]bool)}
+	m.add(idle, now)
+	m.add(active, now)
+	events := idle.subscribe("")
+	<-events // 連線時的完整狀態
+
+	if removed := m.reap(now); removed != 1 {
+		t.Errorf("reap removed %d rooms, want 1", removed)
+	}
+	if m.get(idle.Code) != nil || m.get(active.Code) == nil {
+		t.Errorf("reap kept the wrong rooms")
+	}
+	if _, ok := <-events; ok {
+		t.Errorf("subscriber of a reaped room was not closed")
+	}
+
+	// 背景工作定期清除，不需等到下一次建立房間
+	active.mu.Lock()
+	active.LastActivity = now.Add(-2 * roomIdleTimeout)
+	active.mu.Unlock()
+	stop := m.startReaper(10 * time.Millisecond)
+	defer stop()
+	deadline := time.Now().Add(2 * time.Second)
+	for m.get(active.Code) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("reaper did not remove the idle room")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop() // 重複呼叫不會 panic
+}
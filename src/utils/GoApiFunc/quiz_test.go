@@ -0,0 +1,115 @@
+package GoApiFunc
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// answers 產生同一個單字的多次作答結果
+func answers(word string, correct ...bool) []QuizOutcome {
+	outcomes := make([]QuizOutcome, 0, len(correct))
+	for _, ok := range correct {
+		outcomes = append(outcomes, QuizOutcome{Word: word, Correct: ok})
+	}
+	return outcomes
+}
+
+func TestRecordQuizOutcomesWeights(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    *float64
+		correct  []bool
+		want     float64
+		reviews  int
+		right    int
+		lapses   int
+		streak   int
+		newToday int
+	}{
+		{name: "first correct answer decays", correct: []bool{true}, want: defaultWeight * decayFactor, reviews: 1, right: 1, streak: 1, newToday: 1},
+		{name: "first wrong answer stays at default", correct: []bool{false}, want: defaultWeight, reviews: 1, lapses: 1, newToday: 1},
+		{name: "wrong answer undoes one decay", correct: []bool{true, true, false}, want: defaultWeight * decayFactor, reviews: 3, right: 2, lapses: 1, newToday: 1},
+		{name: "wrong answer never exceeds default", start: ptr(9.5), correct: []bool{false, false}, want: defaultWeight, reviews: 2, lapses: 2},
+		{name: "correct answers stop at minWeight", start: ptr(1.05), correct: []bool{true, true}, want: minWeight, reviews: 2, right: 2, streak: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userData := &UserData{}
+			if tt.start != nil {
+				userData.Progress = map[string]map[string]map[string]float64{"c": {"f": {"apple": *tt.start}}}
+			}
+			now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+			session := recordQuizOutcomes(userData, "c", "f", "", answers("apple", tt.correct...), 0, now)
+
+			if got := userData.Progress["c"]["f"]["apple"]; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("weight = %v, want %v", got, tt.want)
+			}
+			stat := userData.WordStats["c"]["f"]["apple"]
+			if stat == nil {
+				t.Fatal("missing word stat")
+			}
+			if stat.Reviews != tt.reviews || stat.Correct != tt.right || stat.Lapses != tt.lapses || stat.CorrectStreak != tt.streak {
+				t.Errorf("stat = %+v, want reviews=%d correct=%d lapses=%d streak=%d", *stat, tt.reviews, tt.right, tt.lapses, tt.streak)
+			}
+			if today := userData.Daily[userDay(userData, now)]; today.NewWords != tt.newToday || today.Reviewed != tt.reviews {
+				t.Errorf("daily = %+v, want newWords=%d reviewed=%d", *today, tt.newToday, tt.reviews)
+			}
+			if len(session.Changes) != 1 || session.Changes[0].After != userData.Progress["c"]["f"]["apple"] {
+				t.Errorf("changes = %+v", session.Changes)
+			}
+		})
+	}
+}
+
+func TestWrongAnswersNeverReachMastered(t *testing.T) {
+	userData := &UserData{}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		recordQuizOutcomes(userData, "c", "f", "", answers("apple", false), 0, now.Add(time.Duration(i)*time.Minute))
+	}
+
+	weight, seen := userData.Progress["c"]["f"]["apple"]
+	if level := masteryLevel(weight, seen); level == "mastered" {
+		t.Fatalf("word answered wrong 50 times is %q (weight %v)", level, weight)
+	}
+	if weight < defaultWeight {
+		t.Errorf("weight = %v, want at least %v", weight, defaultWeight)
+	}
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func TestRecordQuizOutcomesKeepsModesSeparate(t *testing.T) {
+	userData := &UserData{}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	recordQuizOutcomes(userData, "c", "f", "", answers("apple", true), 0, now)
+	recordQuizOutcomes(userData, "c", "f", quizModeDictation, answers("apple", false), 0, now.Add(time.Hour))
+
+	if got := userData.Progress["c"]["f"]["apple"]; got != defaultWeight*decayFactor {
+		t.Errorf("spelling weight = %v, want %v", got, defaultWeight*decayFactor)
+	}
+	if got := userData.DictationProgress["c"]["f"]["apple"]; got != defaultWeight {
+		t.Errorf("dictation weight = %v, want %v", got, defaultWeight)
+	}
+
+	spelling := userData.WordStats["c"]["f"]["apple"]
+	dictation := userData.DictationStats["c"]["f"]["apple"]
+	if spelling == nil || spelling.Reviews != 1 || spelling.Lapses != 0 || spelling.CorrectStreak != 1 || !spelling.LastReviewed.Equal(now) {
+		t.Errorf("spelling stat = %+v", spelling)
+	}
+	if dictation == nil || dictation.Reviews != 1 || dictation.Lapses != 1 || dictation.LastLapse == nil {
+		t.Errorf("dictation stat = %+v", dictation)
+	}
+
+	// 聽寫答錯不會出現在拼字的錯題複習，反之亦然
+	if got := collectMistakes(userData, "", 0, 7, 2, now.Add(2*time.Hour)); len(got) != 0 {
+		t.Errorf("spelling mistakes = %+v, want none", got)
+	}
+	if got := collectMistakes(userData, quizModeDictation, 0, 7, 2, now.Add(2*time.Hour)); len(got) != 1 || got[0].Word != "apple" {
+		t.Errorf("dictation mistakes = %+v, want apple", got)
+	}
+}
@@ -0,0 +1,174 @@
+package GoApiFunc
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUndoSessions：保留復原資料的最近提交數量，較舊的提交無法復原
+const maxUndoSessions = 20
+
+// WeightChange 記錄一次提交對單一單字的影響
+type WeightChange struct {
+	Word       string    `json:"word"`
+	Before     *float64  `json:"before"` // 提交前的權重，null 代表提交前沒有權重
+	After      float64   `json:"after"`
+	StatBefore *WordStat `json:"statBefore,omitempty"` // 提交前的作答統計，null 代表第一次作答
+	New        bool      `json:"new,omitempty"`        // 是否計入當天的新學單字
+}
+
+// RestoredWord 為復原提交時單一單字的變化
+type RestoredWord struct {
+	Word string    `json:"word"`
+	From float64   `json:"from"` // 復原前（提交後）的權重
+	To   *float64  `json:"to"`   // 復原後的權重，null 代表回到未練習
+	Stat *WordStat `json:"stat"` // 復原後的作答統計，null 代表已移除
+}
+
+// snapshotWord 記錄單字作答前的權重與作答統計
+func snapshotWord(userData *UserData, progress map[string]float64, stats map[string]*WordStat, category, filename, word string) WeightChange {
+	change := WeightChange{Word: word, New: !wordPracticed(userData, category, filename, word)}
+	if weight, exists := progress[word]; exists {
+		change.Before = &weight
+	}
+	if stat, exists := stats[word]; exists {
+		snapshot := *stat
+		change.StatBefore = &snapshot
+	}
+	return change
+}
+
+// findUndoSession 回傳要復原的提交在 History 中的位置：指定 ID 時尋找該提交，否則為最近一次提交
+func findUndoSession(history []QuizSession, sessionID string) int {
+	for i := len(history) - 1; i >= 0; i-- {
+		if sessionID == "" || history[i].ID == sessionID {
+			return i
+		}
+	}
+	return -1
+}
+
+// UndoQuizSubmission 復原最近一次（或以 sessionId 指定的）測驗提交：
+// 權重與作答統計還原為提交前的值，扣除當天的學習紀錄並移除該筆提交紀錄
+// 若提交後相關單字又有變動（再次作答、重設進度等），為避免覆蓋新的進度會拒絕復原
+// 限時挑戰的個人最佳成績不會因復原而改變
+func UndoQuizSubmission(c *gin.Context) {
+	var request struct {
+		SessionID string `json:"sessionId"` // 選填，未提供時復原最近一次提交
+	}
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的提交紀錄 ID"})
+		return
+	}
+
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	index := findUndoSession(userData.History, request.SessionID)
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到可以復原的提交紀錄"})
+		return
+	}
+	session := userData.History[index]
+	if len(session.Changes) == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "此提交紀錄太舊，已無法復原", "sessionId": session.ID})
+		return
+	}
+
+	progressRoot := userData.Progress
+	if session.Mode == quizModeDictation {
+		progressRoot = userData.DictationProgress
+	}
+	progress := progressRoot[session.Category][session.Filename]
+	stats := modeStatsRoot(userData, session.Mode)[session.Category][session.Filename]
+
+	// ✅ 確認每個單字的權重與最後作答時間都還是這次提交留下的結果
+	changed := []string{}
+	for _, change := range session.Changes {
+		weight, exists := progress[change.Word]
+		stat := stats[change.Word]
+		if !exists || weight != change.After || stat == nil || !stat.LastReviewed.Equal(session.Time) {
+			changed = append(changed, change.Word)
+		}
+	}
+	if len(changed) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "部分單字在此提交後已有變動，無法復原",
+			"sessionId": session.ID,
+			"changed":   changed,
+		})
+		return
+	}
+
+	resolver := newEntryResolver(userData)
+	restored := make([]RestoredWord, 0, len(session.Changes))
+	newWords := 0
+	for _, change := range session.Changes {
+		if change.Before != nil {
+			progress[change.Word] = *change.Before
+		} else {
+			deleteWeight(userData, resolver, progressRoot, session.Category, session.Filename, change.Word)
+		}
+		if change.StatBefore != nil {
+			stats[change.Word] = change.StatBefore
+		} else {
+			delete(stats, change.Word)
+		}
+		if change.New {
+			newWords++
+		}
+		restored = append(restored, RestoredWord{Word: change.Word, From: change.After, To: change.Before, Stat: change.StatBefore})
+	}
+	removeEmptyProgress(progressRoot, ProgressLocation{Category: session.Category, Filename: session.Filename})
+
+	// 扣除這次提交計入的當天學習紀錄
+	reviewed, correct := len(session.Outcomes), 0
+	for _, outcome := range session.Outcomes {
+		if outcome.Correct {
+			correct++
+		}
+	}
+	day := userDay(userData, session.Time)
+	if activity := userData.Daily[day]; activity != nil {
+		activity.Reviewed = max(0, activity.Reviewed-reviewed)
+		activity.Correct = max(0, activity.Correct-correct)
+		activity.NewWords = max(0, activity.NewWords-newWords)
+		activity.Seconds = max(0, activity.Seconds-session.Seconds)
+		if activity.empty() {
+			delete(userData.Daily, day)
+		} else if activity.Met != nil {
+			updateDailyMet(activity)
+		}
+	}
+
+	userData.History = append(userData.History[:index], userData.History[index+1:]...)
+
+	if err := SaveUserData(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法儲存學習進度"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "已復原測驗提交",
+		"sessionId": session.ID,
+		"category":  session.Category,
+		"filename":  session.Filename,
+		"mode":      session.Mode,
+		"restored":  restored,
+		"activity": gin.H{
+			"date":     day,
+			"reviewed": reviewed,
+			"correct":  correct,
+			"newWords": newWords,
+			"seconds":  session.Seconds,
+		},
+	})
+}
@@ -0,0 +1,131 @@
+package GoApiFunc
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newQuizRouter 註冊提交與復原測驗的路由（未指定用戶，使用暫存目錄中的 user.json）
+func newQuizRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/quiz/submit", SubmitQuiz)
+	r.POST("/quiz/undo", UndoQuizSubmission)
+	return r
+}
+
+// submitQuiz 提交一次測驗並回傳 sessionId
+func submitQuiz(t *testing.T, r http.Handler, mode string, answers ...QuizOutcome) string {
+	t.Helper()
+	var response struct {
+		SessionID string `json:"sessionId"`
+	}
+	body := gin.H{"category": "undo", "filename": "list", "answers": answers, "mode": mode}
+	if code := postJSON(t, r, "/quiz/submit", body, &response); code != http.StatusOK || response.SessionID == "" {
+		t.Fatalf("submit = %d %+v", code, response)
+	}
+	return response.SessionID
+}
+
+func TestUndoQuizSubmission(t *testing.T) {
+	useTempDataDir(t)
+	writeWordlist(t, "undo", "list", "apple,蘋果,noun", "river,河流,noun")
+	r := newQuizRouter()
+
+	submitQuiz(t, r, "", QuizOutcome{Word: "apple", Correct: true})
+	before, err := GetUserData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitQuiz(t, r, "", QuizOutcome{Word: "apple", Correct: false}, QuizOutcome{Word: "river", Correct: true})
+
+	var undone struct {
+		Restored []RestoredWord `json:"restored"`
+	}
+	if code := postJSON(t, r, "/quiz/undo", gin.H{}, &undone); code != http.StatusOK || len(undone.Restored) != 2 {
+		t.Fatalf("undo = %d %+v", code, undone)
+	}
+
+	after, err := GetUserData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := after.Progress["undo"]["list"]["apple"], before.Progress["undo"]["list"]["apple"]; got != want {
+		t.Errorf("apple weight = %v, want %v", got, want)
+	}
+	if _, exists := after.Progress["undo"]["list"]["river"]; exists {
+		t.Error("river weight should be removed")
+	}
+	if stat := after.WordStats["undo"]["list"]["apple"]; stat == nil || stat.Reviews != 1 || stat.Lapses != 0 {
+		t.Errorf("apple stat = %+v, want the stat before the undone submission", stat)
+	}
+	if len(after.History) != 1 {
+		t.Errorf("history = %d sessions, want 1", len(after.History))
+	}
+	for _, activity := range after.Daily {
+		if activity.Reviewed != 1 || activity.Correct != 1 || activity.NewWords != 1 {
+			t.Errorf("daily = %+v, want only the first submission", *activity)
+		}
+	}
+}
+
+func TestUndoQuizSubmissionConflict(t *testing.T) {
+	useTempDataDir(t)
+	writeWordlist(t, "undo", "list", "apple,蘋果,noun", "river,河流,noun")
+	r := newQuizRouter()
+
+	first := submitQuiz(t, r, "", QuizOutcome{Word: "apple", Correct: true}, QuizOutcome{Word: "river", Correct: true})
+	submitQuiz(t, r, "", QuizOutcome{Word: "apple", Correct: true})
+
+	// apple 在第一次提交後又作答過，復原第一次提交會覆蓋新的進度
+	var conflict struct {
+		Changed []string `json:"changed"`
+	}
+	if code := postJSON(t, r, "/quiz/undo", gin.H{"sessionId": first}, &conflict); code != http.StatusConflict {
+		t.Fatalf("undo = %d, want 409", code)
+	}
+	if len(conflict.Changed) != 1 || conflict.Changed[0] != "apple" {
+		t.Errorf("changed = %v, want [apple]", conflict.Changed)
+	}
+
+	data, err := GetUserData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.History) != 2 || data.Progress["undo"]["list"]["river"] != defaultWeight*decayFactor {
+		t.Errorf("rejected undo must not change anything: history %d, progress %+v", len(data.History), data.Progress)
+	}
+
+	if code := postJSON(t, r, "/quiz/undo", gin.H{"sessionId": "missing"}, nil); code != http.StatusNotFound {
+		t.Errorf("undo unknown session = %d, want 404", code)
+	}
+}
+
+func TestUndoDictationSubmission(t *testing.T) {
+	useTempDataDir(t)
+	writeWordlist(t, "undo", "list", "apple,蘋果,noun")
+	r := newQuizRouter()
+
+	submitQuiz(t, r, "", QuizOutcome{Word: "apple", Correct: true})
+	submitQuiz(t, r, quizModeDictation, QuizOutcome{Word: "apple", Correct: false})
+
+	if code := postJSON(t, r, "/quiz/undo", gin.H{}, nil); code != http.StatusOK {
+		t.Fatalf("undo dictation = %d", code)
+	}
+
+	data, err := GetUserData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := data.DictationStats["undo"]["list"]["apple"]; exists {
+		t.Error("dictation stat should be removed")
+	}
+	if _, exists := data.DictationProgress["undo"]["list"]["apple"]; exists {
+		t.Error("dictation weight should be removed")
+	}
+	if stat := data.WordStats["undo"]["list"]["apple"]; stat == nil || stat.Reviews != 1 || stat.Correct != 1 {
+		t.Errorf("spelling stat = %+v, want it untouched", stat)
+	}
+}
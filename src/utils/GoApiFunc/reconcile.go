@@ -0,0 +1,525 @@
+package GoApiFunc
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 進度整理的建議類型與門檻：
+// reconcileMovedFile：題庫被改名或移到其他分類（依單字重疊比例判斷）
+// reconcileRenamedWord：題庫中的單字被改拼（依編輯距離判斷）
+// minMovedOverlap：孤立題庫的單字至少有這個比例出現在另一個題庫時才建議搬移
+const (
+	reconcileMovedFile   = "moved_file"
+	reconcileRenamedWord = "renamed_word"
+	minMovedOverlap      = 0.5
+)
+
+// ProgressLocation 指向進度中的一個題庫，或題庫中的一個單字（Word 為空時代表整個題庫）
+type ProgressLocation struct {
+	Category string `json:"category"`
+	Filename string `json:"filename"`
+	Word     string `json:"word,omitempty"`
+}
+
+func (l ProgressLocation) String() string {
+	if l.Word == "" {
+		return l.Category + "/" + l.Filename
+	}
+	return l.Category + "/" + l.Filename + ": " + l.Word
+}
+
+// OrphanedEntry 為找不到對應題庫單字的學習進度
+type OrphanedEntry struct {
+	ProgressLocation
+	Weight float64 `json:"weight"`
+	Reason string  `json:"reason"` // missing_file：題庫不存在；missing_word：題庫中沒有此單字
+}
+
+// ReconcileSuggestion 為一筆建議的合併，ID 可用於核准套用
+type ReconcileSuggestion struct {
+	ID       string           `json:"id"`
+	Kind     string           `json:"kind"`
+	From     ProgressLocation `json:"from"`
+	To       ProgressLocation `json:"to"`
+	Score    float64          `json:"score"`              // 信心分數（0～1）
+	Distance int              `json:"distance,omitempty"` // 單字改拼時的編輯距離
+}
+
+// ReconcileReport 為孤立進度的檢查結果
+type ReconcileReport struct {
+	Orphans     []OrphanedEntry       `json:"orphans"`
+	Suggestions []ReconcileSuggestion `json:"suggestions"`
+}
+
+// buildReconcileReport 比對學習進度（拼字與聽寫）與目前的題庫內容，找出孤立的進度並提出合併建議
+func buildReconcileReport(userData *UserData) (ReconcileReport, error) {
+	report := ReconcileReport{Orphans: []OrphanedEntry{}, Suggestions: []ReconcileSuggestion{}}
+
+	wordlists, err := collectWordlists()
+	if err != nil {
+		return report, err
+	}
+	contents := make(map[ProgressLocation][]string)
+	for category, filenames := range wordlists {
+		for _, filename := range filenames {
+			words, err := parseWordlistFile(filepath.Join(wordlistPath, category, filename+".txt"))
+			if err != nil {
+				continue
+			}
+			list := make([]string, 0, len(words))
+			for _, word := range words {
+				list = append(list, word["word"])
+			}
+			contents[ProgressLocation{Category: category, Filename: filename}] = list
+		}
+	}
+
+	for _, location := range progressLocations(userData) {
+		weights := progressWeights(userData, location)
+		list, exists := contents[location]
+
+		// 1️⃣ 題庫已不存在：整個題庫的進度都是孤立的，找單字重疊最多的題庫作為搬移建議
+		if !exists {
+			for _, word := range sortedKeys(weights) {
+				report.Orphans = append(report.Orphans, OrphanedEntry{
+					ProgressLocation: ProgressLocation{Category: location.Category, Filename: location.Filename, Word: word},
+					Weight:           weights[word],
+					Reason:           "missing_file",
+				})
+			}
+			if target, overlap, found := bestMovedTarget(location, weights, contents); found {
+				report.Suggestions = append(report.Suggestions, newReconcileSuggestion(reconcileMovedFile, location, target, overlap, 0))
+			}
+			continue
+		}
+
+		// 2️⃣ 題庫存在但單字不在題庫中：以編輯距離尋找可能的改拼
+		inList := make(map[string]bool, len(list))
+		for _, word := range list {
+			inList[word] = true
+		}
+		for _, word := range sortedKeys(weights) {
+			if inList[word] {
+				continue
+			}
+			from := ProgressLocation{Category: location.Category, Filename: location.Filename, Word: word}
+			report.Orphans = append(report.Orphans, OrphanedEntry{ProgressLocation: from, Weight: weights[word], Reason: "missing_word"})
+
+			if match, distance, found := closestWord(word, list); found {
+				to := ProgressLocation{Category: location.Category, Filename: location.Filename, Word: match}
+				score := 1 - float64(distance)/float64(max(len([]rune(word)), len([]rune(match))))
+				report.Suggestions = append(report.Suggestions, newReconcileSuggestion(reconcileRenamedWord, from, to, score, distance))
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// progressLocations 列出拼字與聽寫進度中所有的題庫（依名稱排序）
+func progressLocations(userData *UserData) []ProgressLocation {
+	seen := make(map[ProgressLocation]bool)
+	var locations []ProgressLocation
+	for _, progress := range []map[string]map[string]map[string]float64{userData.Progress, userData.DictationProgress} {
+		for category, files := range progress {
+			for filename := range files {
+				location := ProgressLocation{Category: category, Filename: filename}
+				if !seen[location] {
+					seen[location] = true
+					locations = append(locations, location)
+				}
+			}
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].String() < locations[j].String() })
+	return locations
+}
+
+// progressWeights 合併題庫的拼字與聽寫權重（同一個單字取拼字權重）
+func progressWeights(userData *UserData, location ProgressLocation) map[string]float64 {
+	weights := make(map[string]float64)
+	for word, weight := range userData.DictationProgress[location.Category][location.Filename] {
+		weights[word] = weight
+	}
+	for word, weight := range userData.Progress[location.Category][location.Filename] {
+		weights[word] = weight
+	}
+	return weights
+}
+
+// bestMovedTarget 找出與孤立題庫單字重疊比例最高的現有題庫；同名題庫在比例相同時優先
+func bestMovedTarget(location ProgressLocation, weights map[string]float64, contents map[ProgressLocation][]string) (ProgressLocation, float64, bool) {
+	candidates := make([]ProgressLocation, 0, len(contents))
+	for candidate := range contents {
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].String() < candidates[j].String() })
+
+	var best ProgressLocation
+	bestOverlap := 0.0
+	for _, candidate := range candidates {
+		matched := 0
+		for _, word := range contents[candidate] {
+			if _, exists := weights[word]; exists {
+				matched++
+			}
+		}
+		overlap := float64(matched) / float64(len(weights))
+		sameName := overlap == bestOverlap && candidate.Filename == location.Filename && best.Filename != location.Filename
+		if overlap > 0 && (overlap > bestOverlap || sameName) {
+			best, bestOverlap = candidate, overlap
+		}
+	}
+	return best, math.Round(bestOverlap*100) / 100, bestOverlap >= minMovedOverlap
+}
+
+// closestWord 在題庫中尋找編輯距離最小的單字（不分大小寫），距離需不超過單字長度的三分之一
+func closestWord(word string, list []string) (string, int, bool) {
+	limit := max(1, len([]rune(word))/3)
+	best, bestDistance := "", limit+1
+	for _, candidate := range list {
+		distance := levenshtein(strings.ToLower(word), strings.ToLower(candidate))
+		if distance < bestDistance || (distance == bestDistance && candidate < best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best, bestDistance, bestDistance <= limit
+}
+
+// levenshtein 計算兩個字串的編輯距離（以 rune 為單位），相鄰字元對調（例如 appel → apple）只算一次
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// newReconcileSuggestion 建立建議並以內容產生固定的 ID，重新檢查時同一筆建議的 ID 不變
+func newReconcileSuggestion(kind string, from, to ProgressLocation, score float64, distance int) ReconcileSuggestion {
+	hash := fnv.New64a()
+	hash.Write([]byte(kind + "|" + from.String() + "|" + to.String()))
+	return ReconcileSuggestion{
+		ID:       fmt.Sprintf("%x", hash.Sum64()),
+		Kind:     kind,
+		From:     from,
+		To:       to,
+		Score:    math.Round(score*100) / 100,
+		Distance: distance,
+	}
+}
+
+// sortedKeys 回傳依字母排序的 key
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// applyProgressMerge 將 from 的拼字、聽寫權重與作答統計合併到 to
+// Word 為空時搬移整個題庫（含限時挑戰的個人最佳成績）；兩邊都有資料時保留較低（較熟）的權重並加總作答統計
+// 來源與目標相同時不做任何事（否則會在寫入目標後刪除同一筆資料）
+func applyProgressMerge(userData *UserData, from, to ProgressLocation) {
+	if from == to {
+		return
+	}
+	if from.Word == "" {
+		for _, progress := range []map[string]map[string]map[string]float64{userData.Progress, userData.DictationProgress} {
+			for word := range progress[from.Category][from.Filename] {
+				mergeWordProgress(progress, from, to, word, word)
+			}
+			removeEmptyProgress(progress, from)
+		}
+		for _, wordStats := range []map[string]map[string]map[string]*WordStat{userData.WordStats, userData.DictationStats} {
+			for word := range wordStats[from.Category][from.Filename] {
+				mergeWordStats(wordStats, from, to, word, word)
+			}
+		}
+		for word := range userData.WordStates[from.Category][from.Filename] {
+			moveWordState(userData, from, to, word, word)
+		}
+		for key, best := range userData.PersonalBests[from.Category][from.Filename] {
+			if current, found := userData.PersonalBests[to.Category][to.Filename][key]; !found || best.beats(current) {
+				recordPersonalBest(userData, to.Category, to.Filename, best)
+			}
+		}
+		delete(userData.PersonalBests[from.Category], from.Filename)
+		return
+	}
+
+	recordEntryAlias(userData, from, to)
+	for _, progress := range []map[string]map[string]map[string]float64{userData.Progress, userData.DictationProgress} {
+		mergeWordProgress(progress, from, to, from.Word, to.Word)
+		removeEmptyProgress(progress, from)
+	}
+	for _, wordStats := range []map[string]map[string]map[string]*WordStat{userData.WordStats, userData.DictationStats} {
+		mergeWordStats(wordStats, from, to, from.Word, to.Word)
+	}
+	moveWordState(userData, from, to, from.Word, to.Word)
+}
+
+// moveWordState 搬移單字的已熟悉 / 暫停標記；目標已有標記時保留目標的標記
+func moveWordState(userData *UserData, from, to ProgressLocation, fromWord, toWord string) {
+	state, exists := userData.WordStates[from.Category][from.Filename][fromWord]
+	if !exists {
+		return
+	}
+	setWordState(userData, from.Category, from.Filename, fromWord, wordStateActive)
+	if _, found := userData.WordStates[to.Category][to.Filename][toWord]; !found {
+		setWordState(userData, to.Category, to.Filename, toWord, state)
+	}
+}
+
+// mergeWordProgress 合併單一單字的權重
+func mergeWordProgress(progress map[string]map[string]map[string]float64, from, to ProgressLocation, fromWord, toWord string) {
+	weight, exists := progress[from.Category][from.Filename][fromWord]
+	if !exists {
+		return
+	}
+	EnsureCategoryAndFilename(progress, to.Category, to.Filename)
+	if current, found := progress[to.Category][to.Filename][toWord]; !found || weight < current {
+		progress[to.Category][to.Filename][toWord] = weight
+	}
+	delete(progress[from.Category][from.Filename], fromWord)
+}
+
+// mergeWordStats 合併單一單字的作答統計（拼字與聽寫的統計分別合併）
+func mergeWordStats(wordStats map[string]map[string]map[string]*WordStat, from, to ProgressLocation, fromWord, toWord string) {
+	stat, exists := wordStats[from.Category][from.Filename][fromWord]
+	if !exists {
+		return
+	}
+	delete(wordStats[from.Category][from.Filename], fromWord)
+	if len(wordStats[from.Category][from.Filename]) == 0 {
+		delete(wordStats[from.Category], from.Filename)
+	}
+
+	stats := ensureWordStats(wordStats, to.Category, to.Filename)
+	if current, found := stats[toWord]; found {
+		stat = mergeStats(current, stat)
+	}
+	stats[toWord] = stat
+}
+
+// removeEmptyProgress 移除已清空的題庫與分類
+func removeEmptyProgress(progress map[string]map[string]map[string]float64, location ProgressLocation) {
+	if files, exists := progress[location.Category]; exists {
+		if len(files[location.Filename]) == 0 {
+			delete(files, location.Filename)
+		}
+		if len(files) == 0 {
+			delete(progress, location.Category)
+		}
+	}
+}
+
+// recordPersonalBest 寫入題庫在該挑戰設定下的個人最佳成績
+func recordPersonalBest(userData *UserData, category, filename string, best PersonalBest) {
+	if userData.PersonalBests == nil {
+		userData.PersonalBests = make(map[string]map[string]map[string]PersonalBest)
+	}
+	if _, exists := userData.PersonalBests[category]; !exists {
+		userData.PersonalBests[category] = make(map[string]map[string]PersonalBest)
+	}
+	if _, exists := userData.PersonalBests[category][filename]; !exists {
+		userData.PersonalBests[category][filename] = make(map[string]PersonalBest)
+	}
+	userData.PersonalBests[category][filename][personalBestKey(best)] = best
+}
+
+// ReconcileRequest 定義套用合併的請求：Apply 為核准的建議 ID，Merges 為手動指定的合併
+type ReconcileRequest struct {
+	Apply  []string `json:"apply"`
+	Merges []struct {
+		From ProgressLocation `json:"from"`
+		To   ProgressLocation `json:"to"`
+	} `json:"merges"`
+}
+
+// GetReconcileReport 列出找不到對應題庫或單字的學習進度，以及可能的合併建議
+func GetReconcileReport(c *gin.Context) {
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+	report, err := buildReconcileReport(userData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取題庫目錄"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ApplyReconcile 套用使用者核准的合併建議（或手動指定的合併），回傳已套用與找不到的項目
+func ApplyReconcile(c *gin.Context) {
+	var req ReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (len(req.Apply) == 0 && len(req.Merges) == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供要套用的建議 ID 或合併項目"})
+		return
+	}
+
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+	report, err := buildReconcileReport(userData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取題庫目錄"})
+		return
+	}
+
+	suggestions := make(map[string]ReconcileSuggestion, len(report.Suggestions))
+	for _, suggestion := range report.Suggestions {
+		suggestions[suggestion.ID] = suggestion
+	}
+
+	applied := []ReconcileSuggestion{}
+	unknown := []string{}
+	for _, id := range req.Apply {
+		suggestion, exists := suggestions[id]
+		if !exists {
+			unknown = append(unknown, id)
+			continue
+		}
+		applyProgressMerge(userData, suggestion.From, suggestion.To)
+		applied = append(applied, suggestion)
+	}
+	for _, merge := range req.Merges {
+		if merge.From.Category == "" || merge.From.Filename == "" || merge.To.Category == "" || merge.To.Filename == "" ||
+			(merge.From.Word == "") != (merge.To.Word == "") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "合併項目需指定分類與題庫，且來源與目標需同時指定或同時省略單字"})
+			return
+		}
+		if merge.From == merge.To {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "合併項目的來源與目標不能相同", "merge": merge})
+			return
+		}
+		kind := reconcileRenamedWord
+		if merge.From.Word == "" {
+			kind = reconcileMovedFile
+		}
+		applyProgressMerge(userData, merge.From, merge.To)
+		applied = append(applied, newReconcileSuggestion(kind, merge.From, merge.To, 1, 0))
+	}
+
+	if len(applied) > 0 {
+		if err := SaveUserData(userData); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "無法儲存學習進度"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"applied": applied, "unknown": unknown})
+}
+
+// RunReconcileCLI 為命令列的進度整理工具（lexiquest reconcile），回傳程式結束代碼
+// 不帶參數時逐筆詢問是否套用建議；-list 只列出結果；-apply all 或 -apply id1,id2 直接套用
+func RunReconcileCLI(args []string, in io.Reader, out io.Writer) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	flags.SetOutput(out)
+	listOnly := flags.Bool("list", false, "只列出孤立的進度與建議，不做任何變更")
+	apply := flags.String("apply", "", "直接套用的建議 ID（以逗號分隔），或 all 套用全部")
+	profile := flags.String("profile", defaultProfile, "要整理的用戶名稱")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if !validProfileName(*profile) || !profileExists(*profile) {
+		fmt.Fprintln(out, "❌ 找不到用戶:", *profile)
+		return 1
+	}
+
+	unlock := lockProfile(*profile)
+	defer unlock()
+	userData, err := loadProfileData(*profile)
+	if err != nil {
+		fmt.Fprintln(out, "❌ 無法讀取用戶資料:", err)
+		return 1
+	}
+	report, err := buildReconcileReport(userData)
+	if err != nil {
+		fmt.Fprintln(out, "❌ 無法讀取題庫目錄:", err)
+		return 1
+	}
+
+	fmt.Fprintf(out, "🔍 找到 %d 筆孤立的進度，%d 筆合併建議\n", len(report.Orphans), len(report.Suggestions))
+	for _, orphan := range report.Orphans {
+		fmt.Fprintf(out, "  • %s（%s，權重 %.2f）\n", orphan.ProgressLocation, orphan.Reason, orphan.Weight)
+	}
+	if *listOnly || len(report.Suggestions) == 0 {
+		for _, suggestion := range report.Suggestions {
+			fmt.Fprintf(out, "  [%s] %s → %s（%s，信心 %.2f）\n", suggestion.ID, suggestion.From, suggestion.To, suggestion.Kind, suggestion.Score)
+		}
+		return 0
+	}
+
+	approved := make(map[string]bool)
+	for _, id := range strings.Split(*apply, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			approved[id] = true
+		}
+	}
+
+	reader := bufio.NewReader(in)
+	applied := 0
+	for _, suggestion := range report.Suggestions {
+		ok := approved["all"] || approved[suggestion.ID]
+		if *apply == "" {
+			fmt.Fprintf(out, "❓ %s → %s（%s，信心 %.2f）套用？[y/N] ", suggestion.From, suggestion.To, suggestion.Kind, suggestion.Score)
+			answer, _ := reader.ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			ok = answer == "y" || answer == "yes"
+		}
+		if !ok {
+			continue
+		}
+		applyProgressMerge(userData, suggestion.From, suggestion.To)
+		applied++
+		fmt.Fprintf(out, "✅ 已合併 %s → %s\n", suggestion.From, suggestion.To)
+	}
+
+	if applied == 0 {
+		fmt.Fprintln(out, "沒有套用任何合併")
+		return 0
+	}
+	if err := SaveUserData(userData); err != nil {
+		fmt.Fprintln(out, "❌ 無法儲存學習進度:", err)
+		return 1
+	}
+	fmt.Fprintf(out, "💾 已套用 %d 筆合併\n", applied)
+	return 0
+}
@@ -0,0 +1,163 @@
+package GoApiFunc
+
+import (
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 錯題複習的預設值：
+// mistakeDefaultDays：未指定範圍時，回溯最近幾天內答錯的單字
+// mistakeDefaultStreak：需要連續答對幾次才從錯題中移除
+const (
+	mistakeDefaultDays   = 7
+	mistakeDefaultStreak = 2
+	mistakeDefaultLimit  = 10
+)
+
+// MistakeWord 為錯題複習中的一個單字
+type MistakeWord struct {
+	Category      string    `json:"category"`
+	Filename      string    `json:"filename"`
+	Word          string    `json:"word"`
+	Translation   string    `json:"translation"`
+	Type          string    `json:"type"`
+	CorrectStreak int       `json:"correctStreak"`
+	Remaining     int       `json:"remaining"` // 還需要連續答對的次數
+	LastLapse     time.Time `json:"lastLapse"`
+}
+
+// GetMistakeReview 跨所有題庫取出最近答錯、且尚未連續答對足夠次數的單字
+// Query 參數：
+//   - sessions：只看最近 N 次提交（優先於 days）
+//   - days：只看最近 N 天（預設 7）
+//   - streak：需連續答對幾次才算完成複習（預設 2）
+//   - limit：最多回傳幾題（預設 10）
+//   - mode：spelling（預設）或 dictation，聽寫的錯題只看聽寫的提交與作答統計
+func GetMistakeReview(c *gin.Context) {
+	mode := c.Query("mode")
+	switch mode {
+	case "", forecastModeSpelling:
+		mode = ""
+	case quizModeDictation:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode 只能是 spelling 或 dictation"})
+		return
+	}
+	sessions := queryPositiveInt(c, "sessions", 0)
+	days := queryPositiveInt(c, "days", mistakeDefaultDays)
+	required := queryPositiveInt(c, "streak", mistakeDefaultStreak)
+	limit := queryPositiveInt(c, "limit", mistakeDefaultLimit)
+
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	mistakes := collectMistakes(userData, mode, sessions, days, required, time.Now())
+	total := len(mistakes)
+	if len(mistakes) > limit {
+		mistakes = mistakes[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"words": mistakes, "total": total, "streak": required})
+}
+
+// collectMistakes 從提交紀錄中找出範圍內答錯的單字，
+// 並依作答統計排除已連續答對 required 次的單字；mode 為 dictation 時只看聽寫，其他模式都算拼字
+func collectMistakes(userData *UserData, mode string, sessions, days, required int, now time.Time) []MistakeWord {
+	history := userData.History
+	if sessions > 0 {
+		if len(history) > sessions {
+			history = history[len(history)-sessions:]
+		}
+	} else {
+		since := now.AddDate(0, 0, -days)
+		start := sort.Search(len(history), func(i int) bool { return !history[i].Time.Before(since) })
+		history = history[start:]
+	}
+
+	type wordKey struct{ category, filename, word string }
+	seen := make(map[wordKey]bool)
+	entries := make(map[string]map[string]map[string]string) // 題庫路徑 → 單字 → 題庫條目
+	var mistakes []MistakeWord
+	wordStats := modeStatsRoot(userData, mode)
+
+	for _, session := range history {
+		if (session.Mode == quizModeDictation) != (mode == quizModeDictation) {
+			continue
+		}
+		for _, outcome := range session.Outcomes {
+			key := wordKey{session.Category, session.Filename, outcome.Word}
+			if outcome.Correct || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			stat := wordStats[key.category][key.filename][key.word]
+			if stat == nil || stat.CorrectStreak >= required || !wordActive(userData.WordStates[key.category][key.filename], key.word) {
+				continue
+			}
+
+			mistake := MistakeWord{
+				Category:      key.category,
+				Filename:      key.filename,
+				Word:          key.word,
+				CorrectStreak: stat.CorrectStreak,
+				Remaining:     required - stat.CorrectStreak,
+			}
+			if stat.LastLapse != nil {
+				mistake.LastLapse = *stat.LastLapse
+			}
+
+			// 從題庫補上翻譯與詞性（同一題庫只解析一次）
+			listKey := filepath.Join(key.category, key.filename)
+			if _, loaded := entries[listKey]; !loaded {
+				entries[listKey] = loadWordlistIndex(key.category, key.filename)
+			}
+			if entry, ok := entries[listKey][key.word]; ok {
+				mistake.Translation = entry["translation"]
+				mistake.Type = entry["type"]
+			}
+
+			mistakes = append(mistakes, mistake)
+		}
+	}
+
+	// 還需要越多次答對、越近期答錯的單字排在前面
+	sort.SliceStable(mistakes, func(i, j int) bool {
+		if mistakes[i].Remaining != mistakes[j].Remaining {
+			return mistakes[i].Remaining > mistakes[j].Remaining
+		}
+		return mistakes[i].LastLapse.After(mistakes[j].LastLapse)
+	})
+
+	return mistakes
+}
+
+// loadWordlistIndex 讀取題庫並以單字為 key 建立索引；題庫不存在時回傳空索引
+func loadWordlistIndex(category, filename string) map[string]map[string]string {
+	index := make(map[string]map[string]string)
+	words, err := parseWordlistFile(filepath.Join(wordlistPath, category, filename+".txt"))
+	if err != nil {
+		return index
+	}
+	for _, word := range words {
+		index[word["word"]] = word
+	}
+	return index
+}
+
+// queryPositiveInt 讀取正整數 Query 參數，無效時回傳預設值
+func queryPositiveInt(c *gin.Context, name string, fallback int) int {
+	value, err := strconv.Atoi(c.Query(name))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,208 @@
+package GoApiFunc
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 學習紀錄統計設定：
+// activityDefaultDays：未指定 from 時預設回傳最近一年（適合 GitHub 風格的熱度圖）
+// activityMaxDays：單次查詢的天數上限
+// forecastDefaultDays / forecastMaxDays：複習預測的預設與最大天數
+// forecastModeSpelling：複習預測只計算拼字進度時的 mode 參數（聽寫為 quizModeDictation）
+const (
+	activityDefaultDays  = 365
+	activityMaxDays      = 3 * 366
+	forecastDefaultDays  = 30
+	forecastMaxDays      = 365
+	forecastModeSpelling = "spelling"
+)
+
+// ActivityDay 為單日的學習紀錄，Level 為熱度圖的顏色深淺（0～4）
+type ActivityDay struct {
+	Date     string  `json:"date"`
+	Reviewed int     `json:"reviewed"`
+	Correct  int     `json:"correct"`
+	NewWords int     `json:"newWords"`
+	Minutes  int     `json:"minutes"`
+	Accuracy float64 `json:"accuracy"` // 答對比例（0～1），沒有練習時為 0
+	Level    int     `json:"level"`
+}
+
+// ForecastDay 為單日預計到期的複習單字數
+type ForecastDay struct {
+	Date string `json:"date"`
+	Due  int    `json:"due"`
+}
+
+// GetActivityStats 回傳 from～to（YYYY-MM-DD，用戶時區）間每一天的複習數、答對數與新學單字數
+// 沒有練習的日子也會列出（數值為 0），方便前端直接繪製熱度圖與趨勢圖
+func GetActivityStats(c *gin.Context) {
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	loc := userLocation(userData)
+	to, _ := time.ParseInLocation(dayLayout, userDay(userData, time.Now()), loc)
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation(dayLayout, value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to 必須是 YYYY-MM-DD 格式"})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -(activityDefaultDays - 1))
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation(dayLayout, value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from 必須是 YYYY-MM-DD 格式"})
+			return
+		}
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from 不能晚於 to"})
+		return
+	}
+	if from.AddDate(0, 0, activityMaxDays).Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "查詢範圍過長"})
+		return
+	}
+
+	days := []ActivityDay{}
+	totals := ActivityDay{}
+	maxReviewed := 0
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		day := ActivityDay{Date: date.Format(dayLayout)}
+		if activity := userData.Daily[day.Date]; activity != nil {
+			day.Reviewed = activity.Reviewed
+			day.Correct = activity.Correct
+			day.NewWords = activity.NewWords
+			day.Minutes = activity.Seconds / 60
+			if activity.Reviewed > 0 {
+				day.Accuracy = math.Round(float64(activity.Correct)/float64(activity.Reviewed)*1000) / 1000
+			}
+		}
+		if day.Reviewed > maxReviewed {
+			maxReviewed = day.Reviewed
+		}
+		totals.Reviewed += day.Reviewed
+		totals.Correct += day.Correct
+		totals.NewWords += day.NewWords
+		totals.Minutes += day.Minutes
+		days = append(days, day)
+	}
+
+	// ✅ 依當日複習數佔期間最大值的比例分為 5 級（0 代表沒有練習）
+	activeDays := 0
+	for i := range days {
+		if days[i].Reviewed == 0 {
+			continue
+		}
+		activeDays++
+		days[i].Level = int(math.Ceil(float64(days[i].Reviewed) / float64(maxReviewed) * 4))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": from.Format(dayLayout),
+		"to":   to.Format(dayLayout),
+		"days": days,
+		"totals": gin.H{
+			"reviewed":   totals.Reviewed,
+			"correct":    totals.Correct,
+			"newWords":   totals.NewWords,
+			"minutes":    totals.Minutes,
+			"activeDays": activeDays,
+		},
+		"maxReviewed": maxReviewed,
+	})
+}
+
+// reviewIntervalDays 依權重估算下次複習的間隔天數：權重越低（越熟）間隔越長
+// 例如權重 10 → 1 天、5 → 2 天、2.5 → 4 天、1 → 10 天
+func reviewIntervalDays(weight float64) int {
+	if weight <= 0 {
+		return 1
+	}
+	return int(math.Max(1, math.Round(defaultWeight/weight)))
+}
+
+// GetReviewForecast 預測接下來 ?days= 天（預設 30 天）每天會到期的複習單字數
+// 到期日為最後練習日加上 reviewIntervalDays；已逾期的單字計入今天並另外列出 overdue
+// 只有作答統計（WordStat）中有最後練習時間的單字才能預測，其餘列為 unscheduled；聽寫進度依聽寫的作答統計排程
+// 預設同時計算拼字與聽寫進度（同一個單字在兩種模式各算一次），可用 ?mode=spelling|dictation 只計算其中一種
+func GetReviewForecast(c *gin.Context) {
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	mode := c.Query("mode")
+	type forecastSource struct {
+		progress  map[string]map[string]map[string]float64
+		wordStats map[string]map[string]map[string]*WordStat
+	}
+	spelling := forecastSource{userData.Progress, userData.WordStats}
+	dictation := forecastSource{userData.DictationProgress, userData.DictationStats}
+	var sources []forecastSource
+	switch mode {
+	case "":
+		sources = append(sources, spelling, dictation)
+	case forecastModeSpelling:
+		sources = append(sources, spelling)
+	case quizModeDictation:
+		sources = append(sources, dictation)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode 只能是 spelling 或 dictation"})
+		return
+	}
+
+	horizon := queryPositiveInt(c, "days", forecastDefaultDays)
+	if horizon > forecastMaxDays {
+		horizon = forecastMaxDays
+	}
+
+	loc := userLocation(userData)
+	today, _ := time.ParseInLocation(dayLayout, userDay(userData, time.Now()), loc)
+
+	days := make([]ForecastDay, horizon)
+	for i := range days {
+		days[i].Date = today.AddDate(0, 0, i).Format(dayLayout)
+	}
+
+	overdue, unscheduled := 0, 0
+	for _, source := range sources {
+		for category, files := range source.progress {
+			for filename, weights := range files {
+				stats := source.wordStats[category][filename]
+				for word, weight := range weights {
+					stat := stats[word]
+					if stat == nil || stat.LastReviewed.IsZero() {
+						unscheduled++
+						continue
+					}
+
+					reviewed, _ := time.ParseInLocation(dayLayout, userDay(userData, stat.LastReviewed), loc)
+					due := reviewed.AddDate(0, 0, reviewIntervalDays(weight))
+					offset := int(math.Round(due.Sub(today).Hours() / 24))
+					if offset < 0 {
+						overdue++
+						offset = 0
+					}
+					if offset < horizon {
+						days[offset].Due++
+					}
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":        days,
+		"overdue":     overdue,
+		"unscheduled": unscheduled,
+	})
+}
@@ -0,0 +1,427 @@
+package GoApiFunc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 限時挑戰的兩種計時方式：
+// timedModeTotal：整份測驗共用一個總時間
+// timedModePerQuestion：每一題各自倒數，從上一題作答（或開始）時起算
+const (
+	timedModeTotal       = "total"
+	timedModePerQuestion = "per_question"
+
+	timedBaseScore      = 100           // 每答對一題的基本分
+	timedMaxSpeedBonus  = 100           // 答題速度的最高加分
+	timedSessionTimeout = 2 * time.Hour // 未完成的挑戰保留時間
+	timedDefaultSeconds = 60            // 未指定時間時的預設秒數
+	timedMaxSeconds     = 60 * 60       // 時間上限，避免不合理的設定
+	timedDefaultLimit   = 10            // 預設題數
+)
+
+// timedQuestion 為伺服器端保存的題目，答案不會回傳給前端
+type timedQuestion struct {
+	Word        string
+	Translation string
+	Type        string
+}
+
+// TimedAnswer 記錄單題的作答內容與伺服器端時間戳記
+type TimedAnswer struct {
+	Index      int       `json:"index"`
+	Answer     string    `json:"answer"`
+	Expected   string    `json:"expected"`
+	Correct    bool      `json:"correct"`
+	TimedOut   bool      `json:"timedOut"`
+	AnsweredAt time.Time `json:"answeredAt"`
+	ElapsedMs  int64     `json:"elapsedMs"`
+	Points     int       `json:"points"`
+}
+
+// timedSession 為一次進行中的限時挑戰
+type timedSession struct {
+	ID         string
+	Profile    string // 開始挑戰的用戶，結算時寫入該用戶的存檔
+	Category   string
+	Filename   string
+	Mode       string
+	Seconds    int
+	StartedAt  time.Time
+	LastEvent  time.Time // 每題倒數模式下，下一題的起算時間
+	Questions  []timedQuestion
+	Answers    map[int]*TimedAnswer
+	FinishedAt time.Time
+}
+
+// ✅ 進行中的挑戰只保存在記憶體中，完成後才把最佳成績寫入 user.json
+var (
+	timedSessions   = make(map[string]*timedSession)
+	timedSessionsMu sync.Mutex
+)
+
+// StartTimedQuizRequest 定義開始限時挑戰的請求資料
+type StartTimedQuizRequest struct {
+	Category string `json:"category"`
+	Filename string `json:"filename"`
+	Limit    int    `json:"limit"`
+	Mode     string `json:"mode"`    // total 或 per_question
+	Seconds  int    `json:"seconds"` // 總時間或每題秒數
+}
+
+// StartTimedQuiz 建立限時挑戰，記錄開始時間並回傳題目（不含答案）
+// 與隨機出題相同，可透過 Query 參數 type / tag / difficulty 篩選題目
+func StartTimedQuiz(c *gin.Context) {
+	var req StartTimedQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Category == "" || req.Filename == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的題庫與挑戰設定"})
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = timedModeTotal
+	}
+	if req.Mode != timedModeTotal && req.Mode != timedModePerQuestion {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode 只能是 total 或 per_question"})
+		return
+	}
+	if req.Seconds <= 0 {
+		req.Seconds = timedDefaultSeconds
+	}
+	if req.Seconds > timedMaxSeconds {
+		req.Seconds = timedMaxSeconds
+	}
+	if req.Limit <= 0 {
+		req.Limit = timedDefaultLimit
+	}
+
+	if !validWordlistRef(req.Category, req.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "題庫名稱不合法"})
+		return
+	}
+	// 讀取題庫內容
+	filePath := filepath.Join(wordlistPath, req.Category, req.Filename+".txt")
+	words, err := parseWordlistFile(filePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法解析題庫"})
+		return
+	}
+	words = filterWords(words, parseWordFilter(c))
+	if len(words) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "題庫為空，無可用單字"})
+		return
+	}
+
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "無法讀取用戶學習進度"})
+		return
+	}
+	userData.applySharedWeights(req.Category, req.Filename)
+	userProgress := userData.Progress
+	EnsureCategoryAndFilename(userProgress, req.Category, req.Filename)
+
+	selected := selectWeightedWords(words, userProgress[req.Category][req.Filename], userData.WordStates[req.Category][req.Filename], "", req.Limit)
+	if len(selected) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "無可用單字"})
+		return
+	}
+
+	now := time.Now()
+	session := &timedSession{
+		ID:        newSessionID(),
+		Profile:   requestProfile(c),
+		Category:  req.Category,
+		Filename:  req.Filename,
+		Mode:      req.Mode,
+		Seconds:   req.Seconds,
+		StartedAt: now,
+		LastEvent: now,
+		Answers:   make(map[int]*TimedAnswer),
+	}
+
+	prompts := make([]gin.H, 0, len(selected))
+	for i, word := range selected {
+		session.Questions = append(session.Questions, timedQuestion{
+			Word:        word["word"],
+			Translation: word["translation"],
+			Type:        word["type"],
+		})
+		prompts = append(prompts, gin.H{"index": i, "translation": word["translation"], "type": word["type"]})
+	}
+
+	timedSessionsMu.Lock()
+	cleanupTimedSessions(now)
+	timedSessions[session.ID] = session
+	timedSessionsMu.Unlock()
+
+	response := gin.H{
+		"sessionId": session.ID,
+		"mode":      session.Mode,
+		"seconds":   session.Seconds,
+		"startedAt": session.StartedAt,
+		"questions": prompts,
+	}
+	if session.Mode == timedModeTotal {
+		response["deadline"] = session.StartedAt.Add(time.Duration(session.Seconds) * time.Second)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// TimedAnswerRequest 定義限時挑戰的單題作答
+type TimedAnswerRequest struct {
+	SessionID string `json:"sessionId"`
+	Index     int    `json:"index"`
+	Answer    string `json:"answer"`
+}
+
+// AnswerTimedQuiz 記錄作答時間並立即判定對錯與得分
+func AnswerTimedQuiz(c *gin.Context) {
+	var req TimedAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.SessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的作答資料"})
+		return
+	}
+
+	timedSessionsMu.Lock()
+	defer timedSessionsMu.Unlock()
+
+	session, exists := timedSessions[req.SessionID]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "挑戰不存在或已過期"})
+		return
+	}
+	if req.Index < 0 || req.Index >= len(session.Questions) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "題號不存在"})
+		return
+	}
+	if _, answered := session.Answers[req.Index]; answered {
+		c.JSON(http.StatusConflict, gin.H{"error": "此題已作答"})
+		return
+	}
+
+	answer := session.recordAnswer(req.Index, req.Answer, time.Now())
+	c.JSON(http.StatusOK, answer)
+}
+
+// recordAnswer 以伺服器時間記錄作答，計算用時與得分
+func (s *timedSession) recordAnswer(index int, answer string, now time.Time) *TimedAnswer {
+	question := s.Questions[index]
+
+	// 每題倒數從上一次作答起算；總時間模式則平均分配每題的時間作為速度基準
+	elapsed := now.Sub(s.LastEvent)
+	budget := time.Duration(s.Seconds) * time.Second
+	allowed := budget
+	timedOut := elapsed > budget
+	if s.Mode == timedModeTotal {
+		allowed = budget / time.Duration(len(s.Questions))
+		timedOut = now.After(s.StartedAt.Add(budget))
+	}
+
+	correct := normalizeAnswer(answer) == normalizeAnswer(question.Word)
+	result := &TimedAnswer{
+		Index:      index,
+		Answer:     answer,
+		Expected:   question.Word,
+		Correct:    correct,
+		TimedOut:   timedOut,
+		AnsweredAt: now,
+		ElapsedMs:  elapsed.Milliseconds(),
+	}
+	if correct && !timedOut {
+		result.Points = timedPoints(elapsed, allowed)
+	}
+
+	s.Answers[index] = result
+	s.LastEvent = now
+	return result
+}
+
+// FinishTimedQuizRequest 定義結束限時挑戰的請求資料
+type FinishTimedQuizRequest struct {
+	SessionID string `json:"sessionId"`
+}
+
+// FinishTimedQuiz 結算限時挑戰：計算總分、更新學習進度，並在破紀錄時更新個人最佳成績
+func FinishTimedQuiz(c *gin.Context) {
+	var req FinishTimedQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.SessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的挑戰 ID"})
+		return
+	}
+
+	// 結算期間先從列表取出，避免同一次挑戰重複計分；存檔失敗時放回，讓前端可以重試
+	timedSessionsMu.Lock()
+	session, exists := timedSessions[req.SessionID]
+	delete(timedSessions, req.SessionID)
+	timedSessionsMu.Unlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "挑戰不存在或已過期"})
+		return
+	}
+	restore := func() {
+		timedSessionsMu.Lock()
+		timedSessions[session.ID] = session
+		timedSessionsMu.Unlock()
+	}
+	if session.FinishedAt.IsZero() {
+		session.FinishedAt = time.Now()
+	}
+
+	result := session.summary()
+
+	unlock := lockProfile(session.Profile)
+	defer unlock()
+	userData, err := loadProfileData(session.Profile)
+	if err != nil {
+		restore()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	// ✅ 作答過的單字同樣計入練習進度與錯題紀錄（逾時答對仍視為答對）
+	var outcomes []QuizOutcome
+	for i, q := range session.Questions {
+		if answer, answered := session.Answers[i]; answered {
+			outcomes = append(outcomes, QuizOutcome{Word: q.Word, Correct: answer.Correct})
+		}
+	}
+	duration := session.FinishedAt.Sub(session.StartedAt)
+	recordQuizOutcomes(userData, session.Category, session.Filename, "timed", outcomes, duration, session.FinishedAt)
+
+	// ✅ 只與相同計時方式、秒數與題數的紀錄比較，分數相同時以較短用時為佳
+	best, hasBest := userData.PersonalBests[session.Category][session.Filename][personalBestKey(result)]
+	isNewBest := !hasBest || result.beats(best)
+	if isNewBest {
+		best = result
+		recordPersonalBest(userData, session.Category, session.Filename, best)
+	}
+
+	if err := SaveUserData(userData); err != nil {
+		restore()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法儲存挑戰結果"})
+		return
+	}
+
+	answers := make([]*TimedAnswer, 0, len(session.Answers))
+	for i := range session.Questions {
+		if answer, answered := session.Answers[i]; answered {
+			answers = append(answers, answer)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"result":          result,
+		"answers":         answers,
+		"personalBest":    best,
+		"newPersonalBest": isNewBest,
+	})
+}
+
+// GetPersonalBests 取得所有題庫的限時挑戰最佳成績（分類 → 題庫 → 挑戰設定，例如 total-60s-10q）
+func GetPersonalBests(c *gin.Context) {
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+	if userData.PersonalBests == nil {
+		userData.PersonalBests = make(map[string]map[string]map[string]PersonalBest)
+	}
+	c.JSON(http.StatusOK, userData.PersonalBests)
+}
+
+// personalBestKey 回傳成績所屬的挑戰設定：計時方式、秒數與題數都相同的成績才互相比較
+func personalBestKey(best PersonalBest) string {
+	return fmt.Sprintf("%s-%ds-%dq", best.Mode, best.Seconds, best.Total)
+}
+
+// beats 判斷成績是否優於另一筆：分數較高，或分數相同但用時較短
+func (b PersonalBest) beats(other PersonalBest) bool {
+	return b.Score > other.Score || (b.Score == other.Score && b.DurationMs < other.DurationMs)
+}
+
+// migrateLegacyBests 將舊版每個題庫一筆的最佳成績依挑戰設定轉入 PersonalBests
+func migrateLegacyBests(userData *UserData) {
+	for category, files := range userData.LegacyBests {
+		for filename, best := range files {
+			if current, exists := userData.PersonalBests[category][filename][personalBestKey(best)]; !exists || best.beats(current) {
+				recordPersonalBest(userData, category, filename, best)
+			}
+		}
+	}
+	userData.LegacyBests = nil
+}
+
+// summary 結算挑戰成績：總分 = 各題得分總和 × 正確率
+func (s *timedSession) summary() PersonalBest {
+	var points, correct int
+	for _, answer := range s.Answers {
+		points += answer.Points
+		if answer.Correct && !answer.TimedOut {
+			correct++
+		}
+	}
+
+	total := len(s.Questions)
+	accuracy := 0.0
+	if total > 0 {
+		accuracy = float64(correct) / float64(total)
+	}
+
+	return PersonalBest{
+		Score:      int(math.Round(float64(points) * accuracy)),
+		Correct:    correct,
+		Total:      total,
+		Accuracy:   accuracy,
+		DurationMs: s.FinishedAt.Sub(s.StartedAt).Milliseconds(),
+		Mode:       s.Mode,
+		Seconds:    s.Seconds,
+		AchievedAt: s.FinishedAt,
+	}
+}
+
+// timedPoints 答對的基本分加上速度加分：越快作答，加分越接近 timedMaxSpeedBonus
+func timedPoints(elapsed, allowed time.Duration) int {
+	if allowed <= 0 {
+		return timedBaseScore
+	}
+	remaining := 1 - float64(elapsed)/float64(allowed)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return timedBaseScore + int(math.Round(remaining*timedMaxSpeedBonus))
+}
+
+// normalizeAnswer 比對答案時忽略大小寫與前後空白，並合併連續空白
+func normalizeAnswer(answer string) string {
+	return strings.ToLower(strings.Join(strings.Fields(answer), " "))
+}
+
+// cleanupTimedSessions 移除逾時未完成的挑戰（呼叫前需持有 timedSessionsMu）
+func cleanupTimedSessions(now time.Time) {
+	for id, session := range timedSessions {
+		if now.Sub(session.StartedAt) > timedSessionTimeout {
+			delete(timedSessions, id)
+		}
+	}
+}
+
+// newSessionID 產生隨機的挑戰 ID
+func newSessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return hex.EncodeToString([]byte(time.Now().Format("150405.000000")))
+	}
+	return hex.EncodeToString(b)
+}
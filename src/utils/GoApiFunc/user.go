@@ -7,14 +7,21 @@ import (
 )
 
 // GetUser 取得完整用戶資料（包含 username 與進度）
-// 直接使用 GetUserData() 讀取存檔資料
+// 直接使用 GetRequestUserData() 讀取請求指定用戶的存檔資料
 func GetUser(c *gin.Context) {
-	userData, err := GetUserData()
+	userData, err := GetRequestUserData(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
 		return
 	}
-	c.JSON(http.StatusOK, userData)
+	c.JSON(http.StatusOK, publicUserData(userData))
+}
+
+// publicUserData 回傳不含 PIN 雜湊的用戶資料副本，用於 API 回應
+func publicUserData(data *UserData) *UserData {
+	public := *data
+	public.Credential = nil
+	return &public
 }
 
 // CreateUserRequest 定義建立用戶的請求資料，僅接收 username
@@ -33,14 +40,22 @@ func CreateUser(c *gin.Context) {
 	newUser := UserData{
 		Username: req.Username,
 		Progress: make(map[string]map[string]map[string]float64),
+		path:     profileFilePath(requestProfile(c)),
 	}
 
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	// ✅ 重新建立時保留原本的角色與 PIN，避免藉此移除 PIN 或取得預設角色
+	if existing, err := GetRequestUserData(c); err == nil {
+		newUser.Role = existing.Role
+		newUser.Credential = existing.Credential
+	}
 	if err := SaveUserData(&newUser); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法建立用戶"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "用戶建立成功", "user": newUser})
+	c.JSON(http.StatusOK, gin.H{"message": "用戶建立成功", "user": publicUserData(&newUser)})
 }
 
 // UpdateUserRequest 定義更新用戶的請求資料，僅更新 username
@@ -50,7 +65,9 @@ type UpdateUserRequest struct {
 
 // UpdateUser 更新用戶的 username，保留原有進度資料
 func UpdateUser(c *gin.Context) {
-	userData, err := GetUserData()
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	userData, err := GetRequestUserData(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
 		return
@@ -69,16 +86,57 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "用戶資料更新成功", "user": userData})
+	c.JSON(http.StatusOK, gin.H{"message": "用戶資料更新成功", "user": publicUserData(userData)})
 }
 
 // GetUserProgressHandler 取得僅用戶的學習進度
-// 保持原有 GetUserProgress() 接口以向後兼容
+// 保持原有 GetUserProgress() 的回傳格式以向後兼容
 func GetUserProgressHandler(c *gin.Context) {
-	progress, err := GetUserProgress()
+	progress, err := GetRequestUserProgress(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶進度"})
 		return
 	}
 	c.JSON(http.StatusOK, progress)
 }
+
+// UpdateSettingsRequest 定義用戶設定的請求資料
+type UpdateSettingsRequest struct {
+	SharedProgress *bool `json:"sharedProgress"` // 不同題庫中相同的單字是否共用學習進度
+	Leaderboard    *bool `json:"leaderboard"`    // 是否公開於區域網排行榜
+}
+
+// UpdateUserSettings 更新用戶設定（未提供的欄位維持原設定）；開啟共用進度時，各題庫中相同單字的權重取最低（最熟）者合併
+func UpdateUserSettings(c *gin.Context) {
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.SharedProgress == nil && req.Leaderboard == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的設定"})
+		return
+	}
+
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	if req.SharedProgress != nil {
+		userData.SharedProgress = *req.SharedProgress
+		if !userData.SharedProgress {
+			userData.Shared = nil
+			userData.SharedDictation = nil
+		}
+	}
+	if req.Leaderboard != nil {
+		userData.Leaderboard = *req.Leaderboard
+	}
+
+	if err := SaveUserData(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法更新用戶設定"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "用戶設定已更新", "sharedProgress": userData.SharedProgress, "leaderboard": userData.Leaderboard})
+}
@@ -2,16 +2,109 @@ package GoApiFunc
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 )
 
 const userFilePath = "./data/userdata/user.json"
 
 // UserData 定義了單一用戶的完整資料，包括 username 與學習進度
 type UserData struct {
-	Username string                                   `json:"username"`
-	Progress map[string]map[string]map[string]float64 `json:"progress"`
+	Username          string                                        `json:"username"`
+	Progress          map[string]map[string]map[string]float64      `json:"progress"`
+	DictationProgress map[string]map[string]map[string]float64      `json:"dictationProgress,omitempty"` // 聽寫模式的單字權重，與拼字練習分開計算
+	PersonalBests     map[string]map[string]map[string]PersonalBest `json:"timedBests,omitempty"`        // 限時挑戰的個人最佳成績（分類 → 題庫 → 挑戰設定，見 personalBestKey）
+	LegacyBests       map[string]map[string]PersonalBest            `json:"personalBests,omitempty"`     // 舊版每個題庫只有一筆的最佳成績，讀取時轉入 PersonalBests
+	WordStats         map[string]map[string]map[string]*WordStat    `json:"wordStats,omitempty"`         // 每個單字的作答統計（分類 → 題庫 → 單字）
+	DictationStats    map[string]map[string]map[string]*WordStat    `json:"dictationStats,omitempty"`    // 聽寫模式的作答統計，與拼字練習分開計算
+	History           []QuizSession                                 `json:"history,omitempty"`           // 最近的測驗提交紀錄
+	Goal              *DailyGoal                                    `json:"goal,omitempty"`              // 每日學習目標
+	Timezone          string                                        `json:"timezone,omitempty"`          // IANA 時區，例如 Asia/Taipei；空值代表伺服器時區
+	Daily             map[string]*DailyActivity                     `json:"daily,omitempty"`             // 每日學習紀錄（依用戶時區的日期 YYYY-MM-DD）
+	WordOfTheDay      []WordOfTheDayPick                            `json:"wordOfTheDay,omitempty"`      // 最近的每日一字，避免短期內重複
+	WordStates        map[string]map[string]map[string]string       `json:"wordStates,omitempty"`        // 單字狀態（分類 → 題庫 → 單字 → known / suspended）
+	Leaderboard       bool                                          `json:"leaderboard,omitempty"`       // 是否公開於區域網排行榜（見 leaderboard.go）
+	Role              string                                        `json:"role,omitempty"`              // 用戶角色 teacher / student；空值依 profileRole 判斷（見 classroom.go）
+	Credential        *ProfileCredential                            `json:"credential,omitempty"`        // 用戶 PIN 的雜湊（見 profile_pin.go），不會在 API 回傳
+
+	SchemaVersion   int                     `json:"schemaVersion,omitempty"`   // user.json 資料版本（見 entry_ids.go）
+	Entries         map[string]*EntryRecord `json:"entries,omitempty"`         // 單字 ID → 最後的拼字與曾用過的拼字
+	IDAliases       map[string]string       `json:"idAliases,omitempty"`       // 舊單字 ID → 新單字 ID（進度整理合併改拼的單字時記錄）
+	SharedProgress  bool                    `json:"sharedProgress,omitempty"`  // 不同題庫中相同的單字是否共用權重
+	Shared          map[string]float64      `json:"shared,omitempty"`          // 共用的拼字權重（單字 ID → 權重）
+	SharedDictation map[string]float64      `json:"sharedDictation,omitempty"` // 共用的聽寫權重
+
+	loadedProgress  map[string]map[string]map[string]float64 // 讀取時的權重，用於判斷共用權重的變更
+	loadedDictation map[string]map[string]map[string]float64
+	orphanIDs       map[string]string // 題庫中已找不到的單字 → 原本的 ID
+	sharedApplied   map[string]bool   // 已套用共用權重的題庫（見 applySharedWeights）
+	path            string            // 讀取的檔案路徑（預設為 user.json，其他用戶檔案見 profiles.go）
+}
+
+// DailyGoal 定義每日目標：複習單字數（words）或學習分鐘數（minutes）
+type DailyGoal struct {
+	Type   string `json:"type"`
+	Target int    `json:"target"`
+}
+
+// DailyActivity 記錄單日的學習量
+type DailyActivity struct {
+	Reviewed int `json:"reviewed"`
+	Correct  int `json:"correct"`
+	NewWords int `json:"newWords"` // 當天第一次練習的單字數
+	Seconds  int `json:"seconds"`  // 當天的學習秒數
+
+	Goal *DailyGoal `json:"goal,omitempty"` // 當天生效的每日目標
+	Met  *bool      `json:"met,omitempty"`  // 當天是否達成當時的目標；舊資料沒有此欄位時以目前的目標判斷
+}
+
+// empty 判斷當天是否已沒有任何學習量
+func (a *DailyActivity) empty() bool {
+	return a.Reviewed == 0 && a.Correct == 0 && a.NewWords == 0 && a.Seconds == 0
+}
+
+// WordStat 記錄單字的作答結果，用於錯題複習
+type WordStat struct {
+	Reviews       int        `json:"reviews"`
+	Correct       int        `json:"correct"`
+	Lapses        int        `json:"lapses"`        // 答錯次數
+	CorrectStreak int        `json:"correctStreak"` // 最近一次答錯後連續答對的次數
+	FirstSeen     time.Time  `json:"firstSeen"`
+	LastReviewed  time.Time  `json:"lastReviewed"`
+	LastLapse     *time.Time `json:"lastLapse,omitempty"`
+}
+
+// QuizSession 記錄一次測驗提交的所有作答結果
+type QuizSession struct {
+	ID       string         `json:"id"`
+	Time     time.Time      `json:"time"`
+	Category string         `json:"category"`
+	Filename string         `json:"filename"`
+	Mode     string         `json:"mode,omitempty"`
+	Outcomes []QuizOutcome  `json:"outcomes"`
+	Seconds  int            `json:"seconds,omitempty"` // 本次作答花費的秒數
+	Changes  []WeightChange `json:"changes,omitempty"` // 作答前後的權重與統計，用於復原提交（只保留最近 maxUndoSessions 次）
+}
+
+// QuizOutcome 記錄單一單字的作答對錯
+type QuizOutcome struct {
+	Word    string `json:"word"`
+	Correct bool   `json:"correct"`
+}
+
+// PersonalBest 記錄某題庫在限時挑戰中的最佳成績
+type PersonalBest struct {
+	Score      int       `json:"score"`
+	Correct    int       `json:"correct"`
+	Total      int       `json:"total"`
+	Accuracy   float64   `json:"accuracy"`
+	DurationMs int64     `json:"durationMs"`
+	Mode       string    `json:"mode"`
+	Seconds    int       `json:"seconds"`
+	AchievedAt time.Time `json:"achievedAt"`
 }
 
 // EnsureCategoryAndFilename 確保進度資料中存在指定的分類與題庫
@@ -26,27 +119,39 @@ func EnsureCategoryAndFilename(userProgress map[string]map[string]map[string]flo
 
 // EnsureUserFile 確保 user.json 存在，若不存在則建立包含預設資料的檔案
 func EnsureUserFile() {
-	dir := filepath.Dir(userFilePath)
+	ensureUserFileAt(userFilePath, "")
+}
+
+// ensureUserFileAt 確保指定的用戶檔案存在，若不存在則以 username 建立預設資料
+func ensureUserFileAt(path, username string) {
+	dir := filepath.Dir(path)
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		os.MkdirAll(dir, os.ModePerm)
 	}
 
-	if _, err := os.Stat(userFilePath); os.IsNotExist(err) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
 		defaultUser := UserData{
-			Username: "",
-			Progress: make(map[string]map[string]map[string]float64),
+			Username:      username,
+			Progress:      make(map[string]map[string]map[string]float64),
+			SchemaVersion: userSchemaVersion,
+		}
+		if err := writeFileAtomic(path, defaultUser); err != nil {
+			fmt.Println("❌ 無法建立用戶檔案:", path, err)
 		}
-		file, _ := os.Create(userFilePath)
-		json.NewEncoder(file).Encode(defaultUser)
-		file.Close()
 	}
 }
 
 // GetUserData 讀取 user.json 並返回完整的 UserData 結構
+// 檔案中的進度以單字 ID 為 key，讀取後轉為目前題庫中的拼字；舊版檔案會先備份，下一次存檔時轉為新版
 func GetUserData() (*UserData, error) {
 	EnsureUserFile()
+	return loadUserData(userFilePath)
+}
 
-	file, err := os.Open(userFilePath)
+// loadUserData 讀取指定路徑的用戶檔案，存檔時會寫回同一個檔案
+// 讀取時不會寫入用戶檔案（未持有檔案鎖的讀取可能與存檔同時進行），舊版資料只在記憶體中轉換，存檔時才寫入新版
+func loadUserData(path string) (*UserData, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -56,19 +161,54 @@ func GetUserData() (*UserData, error) {
 	if err := json.NewDecoder(file).Decode(&data); err != nil {
 		return nil, err
 	}
+	file.Close()
+	data.path = path
+	migrateLegacyBests(&data)
+
+	if data.SchemaVersion < userSchemaVersion {
+		// 舊版檔案本來就以單字為 key，不需要轉換；備份原檔後由下一次存檔寫入新版
+		data.loadedProgress = copyWeights(data.Progress)
+		data.loadedDictation = copyWeights(data.DictationProgress)
+		if err := backupLegacyUserFile(&data); err != nil {
+			fmt.Println("❌ 無法備份", path, err)
+		}
+		return &data, nil
+	}
+
+	data.fromDisk()
 	return &data, nil
 }
 
-// SaveUserData 將完整的 UserData 寫入 user.json
-func SaveUserData(data *UserData) error {
-	EnsureUserFile()
+// ✅ 每個用戶檔案各有一把鎖，同一用戶的並行請求依序進行「讀取 → 修改 → 存檔」，避免互相覆蓋
+var (
+	userFileLocks   = make(map[string]*sync.Mutex)
+	userFileLocksMu sync.Mutex
+)
 
-	file, err := os.Create(userFilePath)
-	if err != nil {
-		return err
+// lockUserFile 鎖定指定的用戶檔案並回傳解鎖函式；修改進度的請求需在讀取前鎖定、存檔後解鎖。
+// 需要同時鎖定其他資料（例如挑戰）時，一律先鎖定用戶檔案
+func lockUserFile(path string) func() {
+	path = filepath.Clean(path)
+	userFileLocksMu.Lock()
+	lock, exists := userFileLocks[path]
+	if !exists {
+		lock = &sync.Mutex{}
+		userFileLocks[path] = lock
 	}
-	defer file.Close()
-	return json.NewEncoder(file).Encode(data)
+	userFileLocksMu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// SaveUserData 將完整的 UserData 寫回讀取時的檔案（預設為 user.json，進度改以單字 ID 為 key）
+// 先寫入暫存檔再改名，寫到一半失敗時不會留下損毀的存檔
+func SaveUserData(data *UserData) error {
+	path := data.path
+	if path == "" {
+		path = userFilePath
+	}
+	return writeFileAtomic(path, data.toDisk())
 }
 
 // GetUserProgress 返回 UserData 中的 Progress 部分
@@ -82,6 +222,8 @@ func GetUserProgress() (map[string]map[string]map[string]float64, error) {
 
 // SaveUserProgress 僅更新 UserData 中的 Progress 部分
 func SaveUserProgress(progress map[string]map[string]map[string]float64) error {
+	unlock := lockProfile(defaultProfile)
+	defer unlock()
 	data, err := GetUserData()
 	if err != nil {
 		// 若讀取失敗，則初始化一個新的 UserData
@@ -0,0 +1,92 @@
+package GoApiFunc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMigrateLegacyBests(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	legacy := PersonalBest{Score: 50, Total: 10, Mode: "spelling", Seconds: 60, AchievedAt: at}
+	better := PersonalBest{Score: 70, Total: 10, Mode: "spelling", Seconds: 60, AchievedAt: at}
+	other := PersonalBest{Score: 30, Total: 20, Mode: "choice", Seconds: 90, AchievedAt: at}
+
+	userData := &UserData{
+		LegacyBests: map[string]map[string]PersonalBest{
+			"daily":  {"basic": legacy},
+			"travel": {"airport": other},
+		},
+		PersonalBests: map[string]map[string]map[string]PersonalBest{
+			"daily": {"basic": {personalBestKey(better): better}},
+		},
+	}
+	migrateLegacyBests(userData)
+
+	if userData.LegacyBests != nil {
+		t.Errorf("LegacyBests = %+v, want nil", userData.LegacyBests)
+	}
+	if got := userData.PersonalBests["daily"]["basic"][personalBestKey(legacy)]; got.Score != better.Score {
+		t.Errorf("daily/basic best = %+v, want the existing better score", got)
+	}
+	if got, exists := userData.PersonalBests["travel"]["airport"][personalBestKey(other)]; !exists || got.Score != other.Score {
+		t.Errorf("travel/airport best = %+v, %v", got, exists)
+	}
+
+	// 舊成績較好時取代新版的成績
+	userData.LegacyBests = map[string]map[string]PersonalBest{"daily": {"basic": {Score: 90, Total: 10, Mode: "spelling", Seconds: 60}}}
+	migrateLegacyBests(userData)
+	if got := userData.PersonalBests["daily"]["basic"][personalBestKey(legacy)]; got.Score != 90 {
+		t.Errorf("daily/basic best = %+v, want 90", got)
+	}
+}
+
+func TestLoadLegacyUserFileWritesOnlyOnSave(t *testing.T) {
+	useTempDataDir(t)
+	path := filepath.Join(profilesDir, "legacy.json")
+	if err := os.MkdirAll(profilesDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	original := []byte(`{"username":"legacy","progress":{"daily":{"basic":{"apple":4.5}}},"personalBests":{"daily":{"basic":{"score":40,"total":10,"mode":"spelling","seconds":60}}}}`)
+	if err := os.WriteFile(path, original, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 讀取時只在記憶體中轉換，不會寫回用戶檔案
+	for i := 0; i < 2; i++ {
+		data, err := loadUserData(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data.Progress["daily"]["basic"]["apple"] != 4.5 || len(data.PersonalBests["daily"]["basic"]) != 1 {
+			t.Fatalf("loaded data = %+v", data)
+		}
+	}
+	if body, _ := os.ReadFile(path); !bytes.Equal(body, original) {
+		t.Errorf("user file rewritten while loading: %s", body)
+	}
+	if backup, err := os.ReadFile(path + ".v1.bak"); err != nil || !bytes.Equal(backup, original) {
+		t.Errorf("backup = %s, %v", backup, err)
+	}
+
+	// 鎖定後存檔才寫入新版
+	unlock := lockUserFile(path)
+	data, err := loadUserData(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveUserData(data); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+
+	saved, err := loadUserData(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.SchemaVersion != userSchemaVersion || saved.Progress["daily"]["basic"]["apple"] != 4.5 || len(saved.PersonalBests["daily"]["basic"]) != 1 || saved.LegacyBests != nil {
+		t.Errorf("saved data = %+v", saved)
+	}
+}
@@ -0,0 +1,260 @@
+package GoApiFunc
+
+import (
+	"net/http"
+	"sort"
+	"time"
+	_ "time/tzdata" // ✅ 內嵌時區資料，Windows 上執行 LexiQuest.exe 時也能解析 IANA 時區
+
+	"github.com/gin-gonic/gin"
+)
+
+// 每日目標的種類與日期格式
+const (
+	goalTypeWords   = "words"
+	goalTypeMinutes = "minutes"
+	dayLayout       = "2006-01-02"
+)
+
+// userLocation 取得用戶設定的時區，未設定或無效時使用伺服器時區
+func userLocation(userData *UserData) *time.Location {
+	if userData.Timezone != "" {
+		if loc, err := time.LoadLocation(userData.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// userDay 回傳指定時間在用戶時區中的日期字串
+func userDay(userData *UserData, t time.Time) string {
+	return t.In(userLocation(userData)).Format(dayLayout)
+}
+
+// ensureDailyActivity 確保當日（用戶時區）的學習紀錄存在並回傳
+func ensureDailyActivity(userData *UserData, now time.Time) *DailyActivity {
+	if userData.Daily == nil {
+		userData.Daily = make(map[string]*DailyActivity)
+	}
+	day := userDay(userData, now)
+	if _, exists := userData.Daily[day]; !exists {
+		userData.Daily[day] = &DailyActivity{}
+	}
+	return userData.Daily[day]
+}
+
+// goalMet 判斷單日學習量是否達成目標；未設定目標時，只要有練習就算達成
+func goalMet(goal *DailyGoal, activity *DailyActivity) bool {
+	if activity == nil {
+		return false
+	}
+	if goal == nil || goal.Target <= 0 {
+		return activity.Reviewed > 0
+	}
+	if goal.Type == goalTypeMinutes {
+		return activity.Seconds >= goal.Target*60
+	}
+	return activity.Reviewed >= goal.Target
+}
+
+// stampDailyGoal 記錄當天生效的目標並更新是否達標；之後修改目標不會影響這一天的判斷
+func stampDailyGoal(activity *DailyActivity, goal *DailyGoal) {
+	activity.Goal = nil
+	if goal != nil {
+		copied := *goal
+		activity.Goal = &copied
+	}
+	updateDailyMet(activity)
+}
+
+// updateDailyMet 以當天記錄的目標重新判斷是否達標（學習量變更後呼叫）
+func updateDailyMet(activity *DailyActivity) {
+	met := goalMet(activity.Goal, activity)
+	activity.Met = &met
+}
+
+// dayGoalMet 判斷某天是否達標：優先使用當天記錄的結果，舊資料才以目前的目標判斷
+func dayGoalMet(userData *UserData, activity *DailyActivity) bool {
+	if activity == nil {
+		return false
+	}
+	if activity.Met != nil {
+		return *activity.Met
+	}
+	return goalMet(userData.Goal, activity)
+}
+
+// freezeDailyGoals 修改目標前，將尚未記錄目標的舊資料以目前的目標固定下來
+func freezeDailyGoals(userData *UserData) {
+	for _, activity := range userData.Daily {
+		if activity != nil && activity.Met == nil {
+			stampDailyGoal(activity, userData.Goal)
+		}
+	}
+}
+
+// goalProgress 回傳單日學習量佔目標的比例（最高為 1）
+func goalProgress(goal *DailyGoal, activity *DailyActivity) float64 {
+	if goal == nil || goal.Target <= 0 {
+		if goalMet(goal, activity) {
+			return 1
+		}
+		return 0
+	}
+
+	var done float64
+	if goal.Type == goalTypeMinutes {
+		done = float64(activity.Seconds) / 60
+	} else {
+		done = float64(activity.Reviewed)
+	}
+	if ratio := done / float64(goal.Target); ratio < 1 {
+		return ratio
+	}
+	return 1
+}
+
+// computeStreaks 計算目前與最長的連續達標天數（每天以當時生效的目標判斷，見 dayGoalMet）
+// 今天尚未達標時不會中斷連續紀錄，而是從昨天開始往回計算
+func computeStreaks(userData *UserData, now time.Time) (current, longest int) {
+	met := make(map[string]bool)
+	var days []string
+	for day, activity := range userData.Daily {
+		if dayGoalMet(userData, activity) {
+			met[day] = true
+			days = append(days, day)
+		}
+	}
+
+	// 最長連續紀錄：依日期排序後計算相鄰的日曆天
+	sort.Strings(days)
+	run := 0
+	var prev time.Time
+	for _, day := range days {
+		date, err := time.Parse(dayLayout, day)
+		if err != nil {
+			continue
+		}
+		if run > 0 && prev.AddDate(0, 0, 1).Equal(date) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		prev = date
+	}
+
+	// 目前連續紀錄：以用戶時區的「今天」為起點往回數
+	date, _ := time.Parse(dayLayout, userDay(userData, now))
+	if !met[date.Format(dayLayout)] {
+		date = date.AddDate(0, 0, -1)
+	}
+	for met[date.Format(dayLayout)] {
+		current++
+		date = date.AddDate(0, 0, -1)
+	}
+
+	return current, longest
+}
+
+// GetUserStats 回傳每日目標、今日進度與連續達標天數
+func GetUserStats(c *gin.Context) {
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	now := time.Now()
+	today := userDay(userData, now)
+	activity := userData.Daily[today]
+	if activity == nil {
+		activity = &DailyActivity{}
+	}
+
+	current, longest := computeStreaks(userData, now)
+
+	activeDays := 0
+	for _, day := range userData.Daily {
+		if day.Reviewed > 0 {
+			activeDays++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"goal":     userData.Goal,
+		"timezone": userLocation(userData).String(),
+		"today": gin.H{
+			"date":     today,
+			"reviewed": activity.Reviewed,
+			"correct":  activity.Correct,
+			"newWords": activity.NewWords,
+			"minutes":  activity.Seconds / 60,
+			"progress": goalProgress(userData.Goal, activity),
+			"met":      goalMet(userData.Goal, activity),
+		},
+		"currentStreak": current,
+		"longestStreak": longest,
+		"activeDays":    activeDays,
+	})
+}
+
+// UpdateGoalRequest 定義更新每日目標的請求資料
+type UpdateGoalRequest struct {
+	Type     string `json:"type"`     // words 或 minutes；留空代表取消目標
+	Target   int    `json:"target"`   // 單字數或分鐘數
+	Timezone string `json:"timezone"` // 選填，IANA 時區名稱
+}
+
+// UpdateGoal 設定每日目標與用戶時區
+func UpdateGoal(c *gin.Context) {
+	var req UpdateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的目標設定"})
+		return
+	}
+	if req.Type != "" && req.Type != goalTypeWords && req.Type != goalTypeMinutes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type 只能是 words 或 minutes"})
+		return
+	}
+	if req.Type != "" && req.Target <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target 必須大於 0"})
+		return
+	}
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的時區: " + req.Timezone})
+			return
+		}
+	}
+
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	// 過去的日子維持原本的目標，新目標只從今天開始生效
+	freezeDailyGoals(userData)
+	userData.Goal = nil
+	if req.Type != "" {
+		userData.Goal = &DailyGoal{Type: req.Type, Target: req.Target}
+	}
+	if req.Timezone != "" {
+		userData.Timezone = req.Timezone
+	}
+	if today := userData.Daily[userDay(userData, time.Now())]; today != nil {
+		stampDailyGoal(today, userData.Goal)
+	}
+
+	if err := SaveUserData(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法更新每日目標"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "每日目標已更新", "goal": userData.Goal, "timezone": userLocation(userData).String()})
+}
@@ -0,0 +1,248 @@
+package GoApiFunc
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 每日一字設定：
+// LEXIQUEST_WOTD_WINDOW：同一個單字在幾天內不會再次被選為每日一字（預設 30 天）
+const defaultWordOfTheDayWindow = 30
+
+// WordOfTheDayPick 記錄某一天選出的每日一字
+type WordOfTheDayPick struct {
+	Date     string `json:"date"` // 用戶時區的日期 YYYY-MM-DD
+	Category string `json:"category"`
+	Filename string `json:"filename"`
+	Word     string `json:"word"`
+}
+
+// wordOfTheDayCandidate 為每日一字的候選單字
+type wordOfTheDayCandidate struct {
+	Category string
+	Filename string
+	Word     map[string]string
+	Weight   float64
+}
+
+// GetWordOfTheDay 回傳今天（用戶時區）的每日一字
+// 以日期與用戶名稱作為亂數種子，同一天重複請求會得到同一個字；
+// 優先從已開始練習的題庫中挑選權重高（較不熟）的單字，且在 window 天內不會重複（?window= 可覆寫設定）
+func GetWordOfTheDay(c *gin.Context) {
+	// 只在讀取與寫入每日一字紀錄時鎖定用戶檔案，查詢字典前就解鎖，避免字典回應慢時擋住其他進度寫入
+	unlock := lockRequestProfile(c)
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		unlock()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	configured := envInt("LEXIQUEST_WOTD_WINDOW", defaultWordOfTheDayWindow)
+	window := queryPositiveInt(c, "window", configured)
+	now := time.Now()
+	today := userDay(userData, now)
+
+	// ✅ 今天已經選過就沿用，確保整天都是同一個字
+	var pick *WordOfTheDayPick
+	for i := range userData.WordOfTheDay {
+		if userData.WordOfTheDay[i].Date == today {
+			pick = &userData.WordOfTheDay[i]
+			break
+		}
+	}
+
+	var chosen *wordOfTheDayCandidate
+	if pick != nil {
+		chosen = findWordOfTheDay(userData, *pick)
+	}
+	if chosen == nil {
+		chosen = pickWordOfTheDay(userData, today, window)
+		if chosen == nil {
+			unlock()
+			c.JSON(http.StatusNotFound, gin.H{"error": "沒有可用的題庫單字"})
+			return
+		}
+		userData.WordOfTheDay = recordWordOfTheDay(userData.WordOfTheDay, WordOfTheDayPick{
+			Date:     today,
+			Category: chosen.Category,
+			Filename: chosen.Filename,
+			Word:     chosen.Word["word"],
+		}, userData, max(window, configured)) // 以較長的天數保留紀錄，避免 ?window= 較小時清掉需要的紀錄
+		if err := SaveUserData(userData); err != nil {
+			unlock()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "無法儲存每日一字"})
+			return
+		}
+	}
+	unlock()
+
+	response := gin.H{
+		"date":        today,
+		"category":    chosen.Category,
+		"filename":    chosen.Filename,
+		"word":        chosen.Word["word"],
+		"translation": chosen.Word["translation"],
+		"type":        chosen.Word["type"],
+		"weight":      chosen.Weight,
+		"window":      window,
+	}
+	if example := chosen.Word["example"]; example != "" {
+		response["example"] = example
+	}
+
+	// 字典資料查詢失敗時仍回傳單字本身，只附上錯誤訊息
+	lang := wordlistLanguage(chosen.Category, chosen.Filename)
+	if data, err := lookupWord(chosen.Word["word"], lang); err == nil {
+		response["dictionary"] = withLocalAudio(data, chosen.Word["word"], lang)
+	} else {
+		_, msg := dictionaryErrorResponse(err, chosen.Word["word"], lang)
+		response["dictionaryError"] = msg
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// pickWordOfTheDay 依日期與用戶決定性地挑選一個單字
+// 已開始練習的題庫優先；window 天內選過的單字會排除，全部被排除時才允許重複
+func pickWordOfTheDay(userData *UserData, today string, window int) *wordOfTheDayCandidate {
+	candidates := wordOfTheDayCandidates(userData, true)
+	if len(candidates) == 0 {
+		candidates = wordOfTheDayCandidates(userData, false)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	recent := make(map[string]bool)
+	cutoff := recentCutoff(userData, today, window)
+	for _, p := range userData.WordOfTheDay {
+		if p.Date >= cutoff {
+			recent[filepath.Join(p.Category, p.Filename, p.Word)] = true
+		}
+	}
+	fresh := make([]wordOfTheDayCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !recent[filepath.Join(candidate.Category, candidate.Filename, candidate.Word["word"])] {
+			fresh = append(fresh, candidate)
+		}
+	}
+	if len(fresh) > 0 {
+		candidates = fresh
+	}
+
+	// ✅ 以「日期 + 用戶名稱」作為種子，依權重抽選（權重越高越容易被選中）
+	hash := fnv.New64a()
+	hash.Write([]byte(today + "|" + userData.Username))
+	rng := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	totalWeight := 0.0
+	for _, candidate := range candidates {
+		totalWeight += candidate.Weight
+	}
+	threshold := rng.Float64() * totalWeight
+	for i := range candidates {
+		threshold -= candidates[i].Weight
+		if threshold < 0 {
+			return &candidates[i]
+		}
+	}
+	return &candidates[len(candidates)-1]
+}
+
+// wordOfTheDayCandidates 收集候選單字並依分類、題庫、單字排序，確保相同種子得到相同結果
+// startedOnly 為 true 時只納入已有練習進度的題庫
+func wordOfTheDayCandidates(userData *UserData, startedOnly bool) []wordOfTheDayCandidate {
+	var candidates []wordOfTheDayCandidate
+
+	categories, err := collectWordlists()
+	if err != nil {
+		return nil
+	}
+	for category, filenames := range categories {
+		for _, filename := range filenames {
+			progress := userData.Progress[category][filename]
+			if startedOnly && len(progress) == 0 {
+				continue
+			}
+
+			words, err := parseWordlistFile(filepath.Join(wordlistPath, category, filename+".txt"))
+			if err != nil {
+				continue
+			}
+			states := userData.WordStates[category][filename]
+			for _, word := range words {
+				if !wordActive(states, word["word"]) {
+					continue
+				}
+				weight, exists := progress[word["word"]]
+				if !exists {
+					weight = defaultWeight
+				}
+				candidates = append(candidates, wordOfTheDayCandidate{
+					Category: category,
+					Filename: filename,
+					Word:     word,
+					Weight:   weight,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Word["word"] < b.Word["word"]
+	})
+	return candidates
+}
+
+// findWordOfTheDay 找回已記錄的每日一字；題庫或單字已被刪除時回傳 nil
+func findWordOfTheDay(userData *UserData, pick WordOfTheDayPick) *wordOfTheDayCandidate {
+	words, err := parseWordlistFile(filepath.Join(wordlistPath, pick.Category, pick.Filename+".txt"))
+	if err != nil {
+		return nil
+	}
+	for _, word := range words {
+		if word["word"] == pick.Word {
+			candidate := &wordOfTheDayCandidate{Category: pick.Category, Filename: pick.Filename, Word: word, Weight: defaultWeight}
+			if weight, exists := userData.Progress[pick.Category][pick.Filename][pick.Word]; exists {
+				candidate.Weight = weight
+			}
+			return candidate
+		}
+	}
+	return nil
+}
+
+// recordWordOfTheDay 加入（或取代）今天的紀錄，並移除 window 天以前的紀錄
+func recordWordOfTheDay(picks []WordOfTheDayPick, pick WordOfTheDayPick, userData *UserData, window int) []WordOfTheDayPick {
+	cutoff := recentCutoff(userData, pick.Date, window)
+	kept := make([]WordOfTheDayPick, 0, len(picks)+1)
+	for _, p := range picks {
+		if p.Date >= cutoff && p.Date != pick.Date {
+			kept = append(kept, p)
+		}
+	}
+	return append(kept, pick)
+}
+
+// recentCutoff 回傳 window 天前的日期字串（含當天），早於此日期的紀錄不再排除
+func recentCutoff(userData *UserData, today string, window int) string {
+	day, err := time.ParseInLocation(dayLayout, today, userLocation(userData))
+	if err != nil {
+		return today
+	}
+	return day.AddDate(0, 0, -(window - 1)).Format(dayLayout)
+}
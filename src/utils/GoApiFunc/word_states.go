@@ -0,0 +1,330 @@
+package GoApiFunc
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 單字狀態：
+// known：已熟悉，不再出題（重設該單字進度時一併清除）
+// suspended：暫停，不再出題，恢復後沿用原本的進度
+// active：一般狀態，用於取消 known / suspended 標記（不會寫入 user.json）
+const (
+	wordStateActive    = "active"
+	wordStateKnown     = "known"
+	wordStateSuspended = "suspended"
+)
+
+// wordActive 判斷單字是否可出題（未標記為已熟悉或暫停）
+func wordActive(states map[string]string, word string) bool {
+	return states[word] == ""
+}
+
+// progressSelection 為重設進度或標記狀態時的篩選條件
+// type / tag / difficulty 與出題篩選相同，minWeight / maxWeight 依目前權重（未練習為 defaultWeight）篩選
+type progressSelection struct {
+	filter    wordFilter
+	minWeight float64
+	maxWeight float64
+}
+
+// parseProgressSelection 讀取篩選條件的 Query 參數
+func parseProgressSelection(c *gin.Context) (progressSelection, error) {
+	selection := progressSelection{filter: parseWordFilter(c), minWeight: 0, maxWeight: math.Inf(1)}
+	for name, target := range map[string]*float64{"minWeight": &selection.minWeight, "maxWeight": &selection.maxWeight} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			return selection, fmt.Errorf("%s 必須是非負數", name)
+		}
+		*target = parsed
+	}
+	if selection.minWeight > selection.maxWeight {
+		return selection, fmt.Errorf("minWeight 不能大於 maxWeight")
+	}
+	return selection, nil
+}
+
+// empty 判斷是否沒有任何篩選條件
+func (s progressSelection) empty() bool {
+	return len(s.filter.Types) == 0 && len(s.filter.Tags) == 0 && len(s.filter.Difficulties) == 0 &&
+		s.minWeight == 0 && math.IsInf(s.maxWeight, 1)
+}
+
+// match 回傳題庫中符合篩選條件的單字
+func (s progressSelection) match(words []map[string]string, progress map[string]float64) []string {
+	var matched []string
+	for _, word := range filterWords(words, s.filter) {
+		weight, exists := progress[word["word"]]
+		if !exists {
+			weight = defaultWeight
+		}
+		if weight >= s.minWeight && weight <= s.maxWeight {
+			matched = append(matched, word["word"])
+		}
+	}
+	return matched
+}
+
+// deleteWeight 刪除單字的權重，回傳是否有權重被刪除
+// 開啟共用進度時，其他題庫中相同 ID 的單字權重也一併刪除，避免下次讀取時被共用權重還原
+func deleteWeight(userData *UserData, resolver *entryResolver, progress map[string]map[string]map[string]float64, category, filename, word string) bool {
+	removed := false
+	id := resolver.idFor(category, filename, word)
+	for c, files := range progress {
+		for f, weights := range files {
+			for w := range weights {
+				sameWord := c == category && f == filename && w == word
+				if sameWord || (userData.SharedProgress && resolver.idFor(c, f, w) == id) {
+					delete(weights, w)
+					removed = true
+				}
+			}
+			if len(weights) == 0 {
+				delete(files, f)
+			}
+		}
+		if len(files) == 0 {
+			delete(progress, c)
+		}
+	}
+	return removed
+}
+
+// resetWord 清除單字的拼字與聽寫權重、作答統計與「已熟悉」標記，回傳是否有資料被清除
+func resetWord(userData *UserData, resolver *entryResolver, category, filename, word string) bool {
+	removed := false
+	for _, progress := range []map[string]map[string]map[string]float64{userData.Progress, userData.DictationProgress} {
+		if deleteWeight(userData, resolver, progress, category, filename, word) {
+			removed = true
+		}
+	}
+
+	for _, wordStats := range []map[string]map[string]map[string]*WordStat{userData.WordStats, userData.DictationStats} {
+		if _, exists := wordStats[category][filename][word]; exists {
+			delete(wordStats[category][filename], word)
+			removed = true
+		}
+	}
+	if userData.WordStates[category][filename][word] == wordStateKnown {
+		setWordState(userData, category, filename, word, wordStateActive)
+		removed = true
+	}
+	return removed
+}
+
+// setWordState 設定單字狀態；active 會移除標記
+func setWordState(userData *UserData, category, filename, word, state string) {
+	if state == wordStateActive {
+		if files, exists := userData.WordStates[category]; exists {
+			delete(files[filename], word)
+			if len(files[filename]) == 0 {
+				delete(files, filename)
+			}
+			if len(files) == 0 {
+				delete(userData.WordStates, category)
+			}
+		}
+		return
+	}
+
+	if userData.WordStates == nil {
+		userData.WordStates = make(map[string]map[string]map[string]string)
+	}
+	if _, exists := userData.WordStates[category]; !exists {
+		userData.WordStates[category] = make(map[string]map[string]string)
+	}
+	if _, exists := userData.WordStates[category][filename]; !exists {
+		userData.WordStates[category][filename] = make(map[string]string)
+	}
+	userData.WordStates[category][filename][word] = state
+}
+
+// ResetWordProgress 重設單一單字的學習進度（權重、聽寫權重、作答統計與「已熟悉」標記）
+func ResetWordProgress(c *gin.Context) {
+	category := c.Param("category")
+	filename := c.Param("filename")
+	word := c.Param("word")
+
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	if !resetWord(userData, newEntryResolver(userData), category, filename, word) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "該單字沒有學習進度"})
+		return
+	}
+	if err := SaveUserData(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法重設學習進度"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("單字 %s 的學習進度已重設", word),
+	})
+}
+
+// ResetFilteredProgress 重設題庫中符合篩選條件的單字進度
+// 以 ?type=、?tag=、?difficulty=、?minWeight=、?maxWeight= 指定範圍；重設整個題庫請使用 DELETE /quiz/progress/:category/:filename
+func ResetFilteredProgress(c *gin.Context) {
+	category := c.Param("category")
+	filename := c.Param("filename")
+
+	selection, err := parseProgressSelection(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if selection.empty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請至少指定一個篩選條件"})
+		return
+	}
+
+	words, err := parseWordlistFile(filepath.Join(wordlistPath, category, filename+".txt"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到指定的題庫"})
+		return
+	}
+
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	userData.applySharedWeights(category, filename)
+	resolver := newEntryResolver(userData)
+	reset := []string{}
+	for _, word := range selection.match(words, userData.Progress[category][filename]) {
+		if resetWord(userData, resolver, category, filename, word) {
+			reset = append(reset, word)
+		}
+	}
+	sort.Strings(reset)
+
+	if len(reset) > 0 {
+		if err := SaveUserData(userData); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "無法重設學習進度"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reset": reset, "count": len(reset)})
+}
+
+// GetWordStates 列出題庫中被標記為已熟悉與暫停的單字
+func GetWordStates(c *gin.Context) {
+	category := c.Param("category")
+	filename := c.Param("filename")
+
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	known, suspended := []string{}, []string{}
+	for word, state := range userData.WordStates[category][filename] {
+		switch state {
+		case wordStateKnown:
+			known = append(known, word)
+		case wordStateSuspended:
+			suspended = append(suspended, word)
+		}
+	}
+	sort.Strings(known)
+	sort.Strings(suspended)
+
+	c.JSON(http.StatusOK, gin.H{"known": known, "suspended": suspended})
+}
+
+// UpdateWordStates 將單字標記為 known / suspended，或以 active 取消標記
+// 以 words 指定單字；未指定時改用與 ResetFilteredProgress 相同的 Query 篩選條件
+func UpdateWordStates(c *gin.Context) {
+	category := c.Param("category")
+	filename := c.Param("filename")
+
+	var request struct {
+		Words []string `json:"words"`
+		State string   `json:"state"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供有效的單字狀態"})
+		return
+	}
+	if request.State != wordStateActive && request.State != wordStateKnown && request.State != wordStateSuspended {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state 只能是 known、suspended 或 active"})
+		return
+	}
+
+	selection, err := parseProgressSelection(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(request.Words) == 0 && selection.empty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供 words 或至少一個篩選條件"})
+		return
+	}
+
+	words, err := parseWordlistFile(filepath.Join(wordlistPath, category, filename+".txt"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到指定的題庫"})
+		return
+	}
+
+	unlock := lockRequestProfile(c)
+	defer unlock()
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶資料"})
+		return
+	}
+
+	userData.applySharedWeights(category, filename)
+	targets := selection.match(words, userData.Progress[category][filename])
+	notFound := []string{}
+	if len(request.Words) > 0 {
+		index := make(map[string]bool, len(words))
+		for _, word := range words {
+			index[word["word"]] = true
+		}
+		targets = targets[:0]
+		for _, word := range request.Words {
+			if index[word] {
+				targets = append(targets, word)
+			} else {
+				notFound = append(notFound, word)
+			}
+		}
+	}
+
+	if targets == nil {
+		targets = []string{}
+	}
+	for _, word := range targets {
+		setWordState(userData, category, filename, word, request.State)
+	}
+	if err := SaveUserData(userData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法更新單字狀態"})
+		return
+	}
+
+	sort.Strings(targets)
+	c.JSON(http.StatusOK, gin.H{"state": request.State, "updated": targets, "notFound": notFound})
+}
@@ -0,0 +1,236 @@
+package GoApiFunc
+
+import (
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 熟練度分級（依單字權重，答對一次權重乘以 decayFactor，答錯一次回升一級）：
+// new：權重 ≥ defaultWeight，未曾練習或答錯後回到預設權重
+// learning：權重介於 learningThreshold 與 defaultWeight 之間（約答對 1～6 次）
+// familiar：權重介於 masteredThreshold 與 learningThreshold 之間（約答對 7～13 次）
+// mastered：權重 ≤ masteredThreshold，或標記為已熟悉（known）的單字
+// 暫停（suspended）的單字另外計算，不列入以上分級（見 wordMastery）
+const (
+	learningThreshold = 5.0
+	masteredThreshold = 2.5
+)
+
+// MasteryCounts 為各熟練度的單字數
+type MasteryCounts struct {
+	New       int `json:"new"`
+	Learning  int `json:"learning"`
+	Familiar  int `json:"familiar"`
+	Mastered  int `json:"mastered"`
+	Suspended int `json:"suspended"`
+}
+
+// add 將一個單字的熟練度（見 wordMastery）計入統計
+func (m *MasteryCounts) add(level string) {
+	switch level {
+	case "new":
+		m.New++
+	case "learning":
+		m.Learning++
+	case "familiar":
+		m.Familiar++
+	case wordStateSuspended:
+		m.Suspended++
+	default:
+		m.Mastered++
+	}
+}
+
+// merge 加總另一份熟練度統計
+func (m *MasteryCounts) merge(other MasteryCounts) {
+	m.New += other.New
+	m.Learning += other.Learning
+	m.Familiar += other.Familiar
+	m.Mastered += other.Mastered
+	m.Suspended += other.Suspended
+}
+
+// WordlistSummary 為單一題庫的進度統計；Mastery 與 Completion 依拼字進度計算，聽寫進度另列於 Dictation
+type WordlistSummary struct {
+	Category   string        `json:"category"`
+	Filename   string        `json:"filename"`
+	Total      int           `json:"total"`
+	NeverSeen  int           `json:"neverSeen"` // 拼字與聽寫進度都沒有紀錄、也沒有標記狀態的單字數
+	Mastery    MasteryCounts `json:"mastery"`
+	Dictation  MasteryCounts `json:"dictation"`  // 聽寫進度的熟練度
+	Completion float64       `json:"completion"` // 完成度百分比（0～100）
+}
+
+// CompactWordlistSummary 為 ListAllWordlists ?summary=compact 使用的精簡統計
+type CompactWordlistSummary struct {
+	Name       string  `json:"name"`
+	Total      int     `json:"total"`
+	Seen       int     `json:"seen"`
+	Mastered   int     `json:"mastered"`
+	Completion float64 `json:"completion"`
+}
+
+// Compact 轉為精簡統計
+func (s WordlistSummary) Compact() CompactWordlistSummary {
+	return CompactWordlistSummary{
+		Name:       s.Filename,
+		Total:      s.Total,
+		Seen:       s.Total - s.NeverSeen,
+		Mastered:   s.Mastery.Mastered,
+		Completion: s.Completion,
+	}
+}
+
+// masteryLevel 依權重判斷熟練度；沒有權重的單字視為 new
+func masteryLevel(weight float64, seen bool) string {
+	switch {
+	case !seen || weight >= defaultWeight:
+		return "new"
+	case weight > learningThreshold:
+		return "learning"
+	case weight > masteredThreshold:
+		return "familiar"
+	default:
+		return "mastered"
+	}
+}
+
+// wordMastery 依權重與單字狀態判斷熟練度：已熟悉（known）的單字視為 mastered，暫停的單字回傳 suspended
+// 題庫統計與作業報告（見 assignmentProgress）共用此規則
+func wordMastery(weight float64, seen bool, state string) string {
+	switch state {
+	case wordStateKnown:
+		return "mastered"
+	case wordStateSuspended:
+		return wordStateSuspended
+	}
+	return masteryLevel(weight, seen)
+}
+
+// wordCompletion 回傳單字的完成度（0～1）：權重從 defaultWeight 降到 masteredThreshold 視為完成
+func wordCompletion(weight float64, seen bool) float64 {
+	if !seen {
+		return 0
+	}
+	return math.Max(0, math.Min(1, (defaultWeight-weight)/(defaultWeight-masteredThreshold)))
+}
+
+// summarizeWordlist 讀取題庫並與進度權重比對，計算各熟練度的單字數與完成度
+// 開啟共用進度時先套用共用權重，與作業報告（見 assignmentProgress）的統計一致
+func summarizeWordlist(userData *UserData, category, filename string) (WordlistSummary, error) {
+	summary := WordlistSummary{Category: category, Filename: filename}
+
+	words, err := parseWordlistFile(filepath.Join(wordlistPath, category, filename+".txt"))
+	if err != nil {
+		return summary, err
+	}
+
+	userData.applySharedWeights(category, filename)
+	progress := userData.Progress[category][filename]
+	dictation := userData.DictationProgress[category][filename]
+	states := userData.WordStates[category][filename]
+
+	completion := 0.0
+	for _, word := range words {
+		state := states[word["word"]]
+		weight, seen := progress[word["word"]]
+		dictationWeight, dictationSeen := dictation[word["word"]]
+		if !seen && !dictationSeen && state == "" {
+			summary.NeverSeen++
+		}
+		summary.Mastery.add(wordMastery(weight, seen, state))
+		summary.Dictation.add(wordMastery(dictationWeight, dictationSeen, state))
+
+		// 已熟悉的單字視為完成；暫停的單字保留原本的進度
+		if state == wordStateKnown {
+			completion++
+		} else {
+			completion += wordCompletion(weight, seen)
+		}
+	}
+
+	summary.Total = len(words)
+	if summary.Total > 0 {
+		summary.Completion = math.Round(completion/float64(summary.Total)*1000) / 10
+	}
+	return summary, nil
+}
+
+// GetWordlistStats 回傳每個題庫的單字總數、未練習數、各熟練度單字數與完成度（拼字與聽寫的熟練度分開列出）
+// 可用 ?category= 與 ?filename= 只統計指定的分類或題庫
+func GetWordlistStats(c *gin.Context) {
+	categories, err := collectWordlists()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "題庫目錄不存在"})
+		return
+	}
+
+	userData, err := GetRequestUserData(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶進度"})
+		return
+	}
+
+	categoryFilter := c.Query("category")
+	filenameFilter := c.Query("filename")
+
+	summaries := []WordlistSummary{}
+	totals := WordlistSummary{}
+	completion := 0.0
+	for category, filenames := range categories {
+		if categoryFilter != "" && category != categoryFilter {
+			continue
+		}
+		for _, filename := range filenames {
+			if filenameFilter != "" && filename != filenameFilter {
+				continue
+			}
+			summary, err := summarizeWordlist(userData, category, filename)
+			if err != nil {
+				continue
+			}
+			summaries = append(summaries, summary)
+
+			totals.Total += summary.Total
+			totals.NeverSeen += summary.NeverSeen
+			totals.Mastery.merge(summary.Mastery)
+			totals.Dictation.merge(summary.Dictation)
+			completion += summary.Completion * float64(summary.Total)
+		}
+	}
+
+	if len(summaries) == 0 && (categoryFilter != "" || filenameFilter != "") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到指定的題庫"})
+		return
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Category != summaries[j].Category {
+			return summaries[i].Category < summaries[j].Category
+		}
+		return summaries[i].Filename < summaries[j].Filename
+	})
+	if totals.Total > 0 {
+		totals.Completion = math.Round(completion/float64(totals.Total)*10) / 10
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"wordlists": summaries,
+		"total": gin.H{
+			"words":      totals.Total,
+			"neverSeen":  totals.NeverSeen,
+			"mastery":    totals.Mastery,
+			"dictation":  totals.Dictation,
+			"completion": totals.Completion,
+		},
+		"thresholds": gin.H{
+			"new":      defaultWeight,
+			"learning": learningThreshold,
+			"mastered": masteredThreshold,
+		},
+	})
+}
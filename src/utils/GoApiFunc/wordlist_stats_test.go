@@ -0,0 +1,64 @@
+package GoApiFunc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeWordlistAppliesSharedWeights(t *testing.T) {
+	useTempDataDir(t)
+	writeWordlist(t, "shared-stats", "first", "apple,蘋果,noun")
+	writeWordlist(t, "shared-stats", "second", "apple,蘋果,noun", "river,河流,noun")
+
+	id := loadWordlistIDs("shared-stats", "second").byWord["apple"]
+	userData := &UserData{
+		SharedProgress: true,
+		Shared:         map[string]float64{id: 3.0},
+		Progress:       map[string]map[string]map[string]float64{"shared-stats": {"first": {"apple": 3.0}}},
+	}
+
+	summary, err := summarizeWordlist(userData, "shared-stats", "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Mastery.Familiar != 1 || summary.NeverSeen != 1 {
+		t.Errorf("summary = %+v, want apple familiar from the shared weight", summary)
+	}
+
+	// 與作業報告的統計一致
+	report := assignmentProgress(userData, Assignment{Category: "shared-stats", Filename: "second", TargetLevel: "familiar", TargetPercent: 50}, time.Now())
+	if report.Mastery != summary.Mastery {
+		t.Errorf("assignment mastery = %+v, stats mastery = %+v", report.Mastery, summary.Mastery)
+	}
+}
+
+func TestSummarizeWordlistWordStates(t *testing.T) {
+	useTempDataDir(t)
+	writeWordlist(t, "state-stats", "list", "apple,蘋果,noun", "river,河流,noun", "orbit,軌道,noun", "cloud,雲,noun")
+
+	userData := &UserData{
+		Progress:          map[string]map[string]map[string]float64{"state-stats": {"list": {"river": 9.0, "orbit": 2.0}}},
+		DictationProgress: map[string]map[string]map[string]float64{"state-stats": {"list": {"cloud": 4.0}}},
+		WordStates:        map[string]map[string]map[string]string{"state-stats": {"list": {"apple": wordStateKnown, "river": wordStateSuspended}}},
+	}
+
+	summary, err := summarizeWordlist(userData, "state-stats", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// apple 已熟悉、orbit 權重已達 mastered、river 暫停、cloud 只有聽寫進度
+	if want := (MasteryCounts{New: 1, Mastered: 2, Suspended: 1}); summary.Mastery != want {
+		t.Errorf("spelling mastery = %+v, want %+v", summary.Mastery, want)
+	}
+	if want := (MasteryCounts{New: 1, Familiar: 1, Mastered: 1, Suspended: 1}); summary.Dictation != want {
+		t.Errorf("dictation mastery = %+v, want %+v", summary.Dictation, want)
+	}
+	if summary.NeverSeen != 0 {
+		t.Errorf("neverSeen = %d, want 0", summary.NeverSeen)
+	}
+
+	report := assignmentProgress(userData, Assignment{Category: "state-stats", Filename: "list", TargetLevel: "mastered", TargetPercent: 50}, time.Now())
+	if report.Mastery != summary.Mastery || report.Reached != 2 {
+		t.Errorf("assignment = %+v, want the same mastery as the stats and 2 reached", report)
+	}
+}
@@ -2,6 +2,7 @@ package GoApiFunc
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -14,26 +15,62 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// 定義題庫的根目錄路徑
-const wordlistPath = "./data/wordlists"
+// 定義題庫的根目錄路徑與分類設定檔名稱
+const (
+	wordlistPath         = "./data/wordlists"
+	categorySettingsFile = "category.json"
+)
 
 // ListAllWordlists 讀取並回傳題庫目錄下的所有分類與檔案
+// 帶 ?summary=compact 時，每個題庫改為回傳名稱與精簡的進度統計
 func ListAllWordlists(c *gin.Context) {
-	categories := make(map[string][]string)
-
 	// 檢查題庫目錄是否存在
 	if _, err := os.Stat(wordlistPath); os.IsNotExist(err) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "題庫目錄不存在"})
 		return
 	}
 
-	// 讀取題庫根目錄中的所有項目
-	files, err := os.ReadDir(wordlistPath)
+	categories, err := collectWordlists()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取題庫目錄"})
 		return
 	}
 
+	// ✅ ?summary=compact 時每個題庫附上精簡的進度統計（見 wordlist_stats.go）
+	if c.Query("summary") == "compact" {
+		userData, err := GetRequestUserData(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "無法讀取用戶進度"})
+			return
+		}
+		compact := make(map[string][]CompactWordlistSummary, len(categories))
+		for category, filenames := range categories {
+			compact[category] = make([]CompactWordlistSummary, 0, len(filenames))
+			for _, filename := range filenames {
+				summary, err := summarizeWordlist(userData, category, filename)
+				if err != nil {
+					continue
+				}
+				compact[category] = append(compact[category], summary.Compact())
+			}
+		}
+		c.JSON(http.StatusOK, compact)
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// collectWordlists 讀取題庫目錄，回傳每個分類中的 .txt 題庫名稱（不含副檔名）
+func collectWordlists() (map[string][]string, error) {
+	categories := make(map[string][]string)
+
+	// 讀取題庫根目錄中的所有項目
+	files, err := os.ReadDir(wordlistPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// 逐個處理目錄，並收集裡面的 .txt 檔案名稱（不含副檔名）
 	for _, file := range files {
 		if file.IsDir() {
@@ -54,7 +91,18 @@ func ListAllWordlists(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, categories)
+	return categories, nil
+}
+
+// validWordlistRef 檢查由請求內容或路徑參數指定的分類與題庫名稱：不可為空、不可含路徑分隔符號或 ".."，
+// 避免拼接路徑後讀取到題庫目錄以外的檔案
+func validWordlistRef(category, filename string) bool {
+	for _, name := range []string{category, filename} {
+		if strings.ContainsAny(name, `/\`) || !filepath.IsLocal(name) || strings.Contains(name, "..") {
+			return false
+		}
+	}
+	return true
 }
 
 // LoadWordlistHandler 讀取並解析指定的題庫文件
@@ -76,7 +124,57 @@ func LoadWordlistHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"category": category, "filename": filename, "data": words})
+	c.JSON(http.StatusOK, gin.H{"category": category, "filename": filename, "lang": wordlistLanguage(category, filename), "data": words})
+}
+
+// wordlistLanguage 取得題庫的來源語言，依序檢查：
+//  1. 題庫檔開頭的註解，例如 `# lang: fr`
+//  2. 分類資料夾中的 category.json，例如 {"lang": "ja"}
+//  3. 預設為英文
+func wordlistLanguage(category, filename string) string {
+	if lang := readWordlistDirective(filepath.Join(wordlistPath, category, filename+".txt"), "lang"); lang != "" {
+		if normalized, ok := normalizeLang(lang); ok {
+			return normalized
+		}
+	}
+
+	var settings struct {
+		Lang string `json:"lang"`
+	}
+	if body, err := os.ReadFile(filepath.Join(wordlistPath, category, categorySettingsFile)); err == nil {
+		if json.Unmarshal(body, &settings) == nil {
+			if normalized, ok := normalizeLang(settings.Lang); ok {
+				return normalized
+			}
+		}
+	}
+
+	return defaultDictionaryLang
+}
+
+// readWordlistDirective 讀取題庫檔開頭註解中的 `# key: value` 設定，遇到第一個單字條目即停止
+func readWordlistDirective(filePath, key string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		name, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "#")), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), key) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
 }
 
 // parseWordlistFile 解析 .txt 文件並回傳單字資料
@@ -103,11 +201,32 @@ func parseWordlistFile(filePath string) ([]map[string]string, error) {
 			continue // 跳過格式錯誤的行
 		}
 
-		words = append(words, map[string]string{
+		entry := map[string]string{
 			"word":        strings.TrimSpace(parts[0]),
 			"translation": strings.TrimSpace(parts[1]),
 			"type":        strings.TrimSpace(parts[2]),
-		})
+		}
+
+		// 第 4 欄之後為選填的 key=value 欄位，例如 tags=daily|travel、difficulty=2
+		for i, extra := range parts[3:] {
+			key, value, ok := strings.Cut(extra, "=")
+			key = strings.ToLower(strings.TrimSpace(key))
+			if !ok || key == "" || key == "word" || key == "translation" || key == "type" {
+				continue
+			}
+			// 例句本身可能含有逗號，因此 example 必須放在最後並吃掉剩餘內容
+			if key == "example" {
+				value = strings.Join(append([]string{value}, parts[3+i+1:]...), ",")
+				entry[key] = strings.TrimSpace(value)
+				break
+			}
+			entry[key] = strings.TrimSpace(value)
+		}
+
+		// 單字 ID：id= 欄位或單字內容的雜湊，學習進度以此記錄（見 entry_ids.go）
+		entry["id"] = entryID(entry)
+
+		words = append(words, entry)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -144,12 +263,21 @@ func GetRandomWordlist(c *gin.Context) {
 		return
 	}
 
-	// 讀取用戶學習進度
-	userProgress, err := GetUserProgress()
+	// ✅ 依 Query 參數（type / tag / difficulty）先篩選題目，再進行加權選擇
+	words = filterWords(words, parseWordFilter(c))
+	if len(words) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "沒有符合篩選條件的單字"})
+		return
+	}
+
+	// 讀取用戶學習進度與單字狀態
+	userData, err := GetRequestUserData(c)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "無法讀取用戶學習進度"})
 		return
 	}
+	userData.applySharedWeights(category, filename)
+	userProgress := userData.Progress
 
 	// 確保進度數據存在，避免 nil 錯誤
 	if _, exists := userProgress[category]; !exists {
@@ -159,20 +287,40 @@ func GetRandomWordlist(c *gin.Context) {
 		userProgress[category][filename] = make(map[string]float64)
 	}
 
-	// 加權隨機選擇多個單字，排除 lastWord
+	// 加權隨機選擇多個單字，排除 lastWord 與已熟悉、暫停的單字
+	selectedWords := selectWeightedWords(words, userProgress[category][filename], userData.WordStates[category][filename], lastWord, limit)
+
+	// **✅ 確保 API 一定回傳至少 1 個單字**
+	if len(selectedWords) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "無可用單字"})
+		return
+	}
+
+	c.JSON(http.StatusOK, selectedWords)
+}
+
+// selectWeightedWords 以加權隨機方式選出最多 limit 個不重複的單字，第一個選擇會排除 lastWord
+// states 中標記為已熟悉或暫停的單字不會被選出
+func selectWeightedWords(words []map[string]string, progress map[string]float64, states map[string]string, lastWord string, limit int) []map[string]string {
 	selectedWords := make([]map[string]string, 0, limit)
 	usedWords := make(map[string]bool) // 紀錄已選過的單字，避免重複
 
 	// **✅ 先確保第一個選擇不會重複 `lastWord`**
-	firstWord := weightedRandomSelection(words, userProgress[category][filename], lastWord)
+	firstWord := weightedRandomSelection(words, progress, states, lastWord)
 	if firstWord != nil {
 		usedWords[firstWord["word"]] = true
 		selectedWords = append(selectedWords, firstWord)
 	}
 
-	// **✅ 修正：確保選不超過 `words` 的總數量**
-	for len(selectedWords) < limit && len(selectedWords) < len(words) {
-		word := weightedRandomSelection(words, userProgress[category][filename], "")
+	// **✅ 修正：確保選不超過可出題單字的總數量**
+	available := 0
+	for _, word := range words {
+		if wordActive(states, word["word"]) {
+			available++
+		}
+	}
+	for len(selectedWords) < limit && len(selectedWords) < available {
+		word := weightedRandomSelection(words, progress, states, "")
 		if word == nil {
 			break // **避免無可用單字時進入無限迴圈**
 		}
@@ -184,21 +332,23 @@ func GetRandomWordlist(c *gin.Context) {
 		selectedWords = append(selectedWords, word)
 	}
 
-	// **✅ 確保 API 一定回傳至少 1 個單字**
-	if len(selectedWords) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "無可用單字"})
-		return
-	}
-
-	c.JSON(http.StatusOK, selectedWords)
+	return selectedWords
 }
 
 // weightedRandomSelection 根據各單字的權重進行隨機選擇；若 exclude 不為空且候選不只有一項，則先排除該單字
-func weightedRandomSelection(words []map[string]string, progress map[string]float64, exclude string) map[string]string {
-	filteredWords := words
-	if exclude != "" && len(words) > 1 {
+// 標記為已熟悉或暫停的單字（states）不列入候選，全部被排除時回傳 nil
+func weightedRandomSelection(words []map[string]string, progress map[string]float64, states map[string]string, exclude string) map[string]string {
+	var activeWords []map[string]string
+	for _, word := range words {
+		if wordActive(states, word["word"]) {
+			activeWords = append(activeWords, word)
+		}
+	}
+
+	filteredWords := activeWords
+	if exclude != "" && len(activeWords) > 1 {
 		var temp []map[string]string
-		for _, word := range words {
+		for _, word := range activeWords {
 			if word["word"] != exclude {
 				temp = append(temp, word)
 			}
@@ -248,3 +398,71 @@ func weightedRandomSelection(words []map[string]string, progress map[string]floa
 
 	return nil
 }
+
+// wordFilter 定義出題前的篩選條件，同一欄位可用逗號指定多個值（任一符合即可）
+type wordFilter struct {
+	Types        []string
+	Tags         []string
+	Difficulties []string
+}
+
+// parseWordFilter 從 Query 參數 type、tag、difficulty 讀取篩選條件
+// 例如 ?type=verb,phrase&tag=travel&difficulty=1,2
+func parseWordFilter(c *gin.Context) wordFilter {
+	return wordFilter{
+		Types:        splitFilterValues(c.Query("type")),
+		Tags:         splitFilterValues(c.Query("tag")),
+		Difficulties: splitFilterValues(c.Query("difficulty")),
+	}
+}
+
+// splitFilterValues 將逗號分隔的參數轉為小寫並去除空白
+func splitFilterValues(raw string) []string {
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// filterWords 回傳符合篩選條件的單字；沒有任何條件時原樣回傳
+func filterWords(words []map[string]string, filter wordFilter) []map[string]string {
+	if len(filter.Types) == 0 && len(filter.Tags) == 0 && len(filter.Difficulties) == 0 {
+		return words
+	}
+
+	var filtered []map[string]string
+	for _, word := range words {
+		// 詞性可能寫成 noun/verb，任一詞性符合即可
+		if len(filter.Types) > 0 && !matchesAny(strings.Split(word["type"], "/"), filter.Types) {
+			continue
+		}
+		if len(filter.Tags) > 0 && !matchesAny(strings.Split(word["tags"], "|"), filter.Tags) {
+			continue
+		}
+		if len(filter.Difficulties) > 0 && !matchesAny([]string{word["difficulty"]}, filter.Difficulties) {
+			continue
+		}
+		filtered = append(filtered, word)
+	}
+	return filtered
+}
+
+// matchesAny 判斷 values 中是否有任一值（不分大小寫）出現在 wanted 內
+func matchesAny(values []string, wanted []string) bool {
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			continue
+		}
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}